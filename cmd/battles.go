@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// runBattles implements "loggob battles": list stored battles matching a query.
+func runBattles(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("battles", flag.ExitOnError)
+	tag := fs.String("tag", cfg.PlayerTag, "player whose battles to list (defaults to PLAYERTAG)")
+	opponent := fs.String("opponent", "", "only battles against this player")
+	since := fs.String("since", "", "only battles on or after this date (YYYY-MM-DD)")
+	until := fs.String("until", "", "only battles before this date (YYYY-MM-DD)")
+	battleTypes := fs.String("type", "", "comma-separated battle types, e.g. PvP,pathOfLegend")
+	modes := fs.String("mode", "", "comma-separated game mode IDs")
+	arenas := fs.String("arena", "", "comma-separated arena IDs")
+	result := fs.String("result", "", "win, loss or draw")
+	minTrophies := fs.Int("min-trophies", 0, "minimum starting trophies")
+	maxTrophies := fs.Int("max-trophies", 0, "maximum starting trophies")
+	cards := fs.String("card", "", "comma-separated cards (names or IDs) the player's deck must contain")
+	oppCards := fs.String("opponent-card", "", "comma-separated cards (names or IDs) the opponent's deck must contain")
+	deck := fs.String("deck", "", "deck hash the player's deck must match")
+	limit := fs.Int("limit", 25, "maximum number of battles to list; 0 lists all")
+	count := fs.Bool("count", false, "only print how many battles match")
+	fs.Parse(args)
+
+	if *tag == "" {
+		return errors.New("PLAYERTAG not set in environment variables and no -tag given")
+	}
+	playerTag, err := types.NormalizeTag(*tag)
+	if err != nil {
+		return err
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	q := storage.BattleQuery{
+		PlayerTag:   playerTag,
+		MinTrophies: int32(*minTrophies),
+		MaxTrophies: int32(*maxTrophies),
+		DeckHash:    *deck,
+	}
+
+	if *opponent != "" {
+		if q.OpponentTag, err = types.NormalizeTag(*opponent); err != nil {
+			return err
+		}
+	}
+	if q.Since, err = parseDate(*since); err != nil {
+		return err
+	}
+	if q.Until, err = parseDate(*until); err != nil {
+		return err
+	}
+	if q.Result, err = storage.ParseResult(*result); err != nil {
+		return err
+	}
+	q.Filter.Types = splitList(*battleTypes)
+	if q.Filter.GameModeIDs, err = parseIDs(*modes); err != nil {
+		return err
+	}
+	if q.ArenaIDs, err = parseIDs(*arenas); err != nil {
+		return err
+	}
+	if q.Cards, err = resolveCards(s, *cards); err != nil {
+		return err
+	}
+	if q.OpponentCards, err = resolveCards(s, *oppCards); err != nil {
+		return err
+	}
+
+	if *count {
+		n, err := s.CountBattles(q)
+		if err != nil {
+			return err
+		}
+		fmt.Println(n)
+		return nil
+	}
+
+	battles, _, err := s.QueryBattles(q, storage.Page{Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tRESULT\tCROWNS\tTROPHIES\tOPPONENT\tMODE\tDECK")
+	for _, b := range battles {
+		me, opp := sides(&b, q.PlayerTag)
+		if me == nil || opp == nil {
+			continue
+		}
+
+		when := b.BattleTime
+		if t, err := b.Time(); err == nil {
+			when = t.Local().Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d-%d\t%d (%+d)\t%s %s\t%s\t%s\n",
+			when, outcome(me.Crowns, opp.Crowns), me.Crowns, opp.Crowns,
+			me.StartingTrophies, me.TrophyChange, opp.Name, opp.Tag,
+			b.GameMode.Name, me.DeckHash())
+	}
+	return w.Flush()
+}
+
+// sides returns tag's participant record and the first player on the other side.
+func sides(b *types.Battle, tag string) (me, opp *types.Player) {
+	for i := range b.Team {
+		if b.Team[i].Tag == tag && len(b.Opponent) > 0 {
+			return &b.Team[i], &b.Opponent[0]
+		}
+	}
+	for i := range b.Opponent {
+		if b.Opponent[i].Tag == tag && len(b.Team) > 0 {
+			return &b.Opponent[i], &b.Team[0]
+		}
+	}
+	return nil, nil
+}
+
+func outcome(mine, theirs int32) string {
+	switch {
+	case mine > theirs:
+		return "win"
+	case mine < theirs:
+		return "loss"
+	}
+	return "draw"
+}
+
+// parseDate parses a YYYY-MM-DD date in local time; an empty string yields the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseIDs parses a comma-separated list of numeric IDs.
+func parseIDs(s string) ([]int32, error) {
+	var ids []int32
+	for _, v := range splitList(s) {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", v)
+		}
+		ids = append(ids, int32(id))
+	}
+	return ids, nil
+}
+
+// resolveCards turns a comma-separated list of card names or IDs into card IDs.
+func resolveCards(s storage.Store, list string) ([]int32, error) {
+	var ids []int32
+	for _, v := range splitList(list) {
+		if id, err := strconv.ParseInt(v, 10, 32); err == nil {
+			ids = append(ids, int32(id))
+			continue
+		}
+		id, err := s.FindCard(v)
+		if err != nil {
+			return nil, fmt.Errorf("unknown card %q", v)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
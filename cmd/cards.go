@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/elliot727/log-gob/internal/config"
+)
+
+// runCards implements "loggob cards sync": refresh the global card catalog from /v1/cards.
+func runCards(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return errors.New("usage: loggob cards sync")
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	cards, src, err := client.Cards(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch card catalog: %w", err)
+	}
+	if src.Cached() {
+		slog.Info("Card catalog unchanged")
+		return nil
+	}
+
+	if err := s.SyncCardCatalog(cards); err != nil {
+		return fmt.Errorf("failed to save card catalog: %w", err)
+	}
+	slog.Info("Synced card catalog", "cards", len(cards))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/storage"
+)
+
+// runClan implements "loggob clan <subcommand>".
+func runClan(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "sync" {
+		return errors.New("usage: loggob clan sync [-tag CLANTAG] [-battles=false]")
+	}
+
+	fs := flag.NewFlagSet("clan sync", flag.ExitOnError)
+	tag := fs.String("tag", cfg.ClanTag, "clan tag to sync (defaults to CLAN_TAG)")
+	battles := fs.Bool("battles", true, "also fetch every member's battle log")
+	fs.Parse(args[1:])
+
+	if *tag == "" {
+		return errors.New("CLAN_TAG not set in environment variables and no -tag given")
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return syncClan(ctx, client, s, *tag, *battles)
+}
+
+// syncClan stores the clan, its members and river race participation, then
+// optionally fetches the battle log of every member.
+func syncClan(ctx context.Context, client *api.Client, s storage.Store, tag string, withBattles bool) error {
+	// Unchanged responses still carry the cached data, which is cheap to re-save
+	clan, _, err := client.Clan(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch clan %s: %w", tag, err)
+	}
+
+	members, _, err := client.ClanMembers(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch members of %s: %w", tag, err)
+	}
+
+	if err := s.SaveClan(clan, members); err != nil {
+		return fmt.Errorf("failed to save clan: %w", err)
+	}
+	slog.Info("Saved clan", "clan", clan.Tag, "name", clan.Name, "members", len(members))
+
+	race, _, err := client.CurrentRiverRace(ctx, tag)
+	switch {
+	case errors.Is(err, api.ErrNotFound):
+		slog.Info("No river race in progress", "clan", clan.Tag)
+	case err != nil:
+		return fmt.Errorf("failed to fetch current river race: %w", err)
+	default:
+		if err := s.SaveRiverRace(clan.Tag, storage.CurrentRaceID, race.Clan.Participants); err != nil {
+			return fmt.Errorf("failed to save current river race: %w", err)
+		}
+		slog.Info("Saved current river race", "clan", clan.Tag, "participants", len(race.Clan.Participants))
+	}
+
+	raceLog, _, err := client.RiverRaceLog(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch river race log: %w", err)
+	}
+	for _, entry := range raceLog {
+		for _, standing := range entry.Standings {
+			if standing.Clan.Tag != clan.Tag {
+				continue
+			}
+			raceID := storage.RaceID(entry.SeasonID, entry.SectionIndex)
+			if err := s.SaveRiverRace(clan.Tag, raceID, standing.Clan.Participants); err != nil {
+				return fmt.Errorf("failed to save river race %s: %w", raceID, err)
+			}
+		}
+	}
+	slog.Info("Saved finished river races", "clan", clan.Tag, "races", len(raceLog))
+
+	if !withBattles {
+		return nil
+	}
+
+	total := 0
+	for _, m := range members {
+		res, err := fetchPlayer(ctx, client, s, m.Tag)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// One member failing shouldn't stop the rest of the clan from syncing
+			slog.Error("Fetch failed", logging.KeyTag, m.Tag, "err", err)
+			continue
+		}
+		total += res.Inserted
+	}
+	slog.Info("Synced member battles", "clan", clan.Tag, "inserted", total, "members", len(members))
+
+	return nil
+}
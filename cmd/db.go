@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/elliot727/log-gob/internal/config"
+)
+
+// runDB implements "loggob db migrate", "loggob db status" and "loggob db reparse".
+func runDB(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: loggob db migrate|status|reparse")
+	}
+
+	s, closeDB, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch args[0] {
+	case "migrate":
+		// Storage logs each migration as it is applied
+		applied, err := s.Migrate()
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			slog.Info("Database schema is up to date")
+		}
+		return nil
+
+	case "status":
+		status, err := s.MigrationStatus()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, m := range status {
+			applied := "pending"
+			if m.Applied {
+				applied = m.AppliedAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, applied)
+		}
+		return w.Flush()
+
+	case "reparse":
+		if err := s.Init(); err != nil {
+			return fmt.Errorf("failed to initialize storage: %w", err)
+		}
+
+		n, err := s.Reparse(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to reparse archived battles: %w", err)
+		}
+		slog.Info("Rebuilt battles from the raw archive", "battles", n)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown db command %q (want migrate, status or reparse)", args[0])
+	}
+}
@@ -0,0 +1,54 @@
+// Command fakeapi serves a fake Clash Royale API from recorded fixtures for offline development.
+//
+// Record fixtures by running loggob with API_RECORD_DIR set, then point
+// API_BASE_URL at this server (or use API_REPLAY_DIR to skip HTTP entirely).
+// Logging honors LOG_LEVEL, LOG_FORMAT and LOG_FILE like loggob.
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/elliot727/log-gob/internal/fakeapi"
+	"github.com/elliot727/log-gob/internal/logging"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "address to listen on")
+	dir := flag.String("dir", "fixtures", "directory of recorded fixtures")
+	flag.Parse()
+
+	if err := setupLogging(); err != nil {
+		slog.Error("Failed to set up logging", "err", err)
+		os.Exit(1)
+	}
+
+	s, err := fakeapi.NewFromDir(*dir)
+	if err != nil {
+		slog.Error("Failed to load fixtures", "dir", *dir, "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Serving fixtures", "dir", *dir, "addr", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		slog.Error("Server stopped", "addr", *addr, "err", err)
+		os.Exit(1)
+	}
+}
+
+// setupLogging installs the default logger from the LOG_* environment variables.
+// The log file, if any, stays open until the process exits.
+func setupLogging() error {
+	level, err := logging.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return err
+	}
+	format, err := logging.ParseFormat(os.Getenv("LOG_FORMAT"))
+	if err != nil {
+		return err
+	}
+	_, err = logging.Setup(format, level, os.Getenv("LOG_FILE"), os.Stderr)
+	return err
+}
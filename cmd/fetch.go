@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// runFetch implements "loggob fetch": fetch tracked players' battle logs and store them.
+// Every player shares one client, so its rate limit and API keys cover the whole run.
+func runFetch(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	tag := fs.String("tag", "", "player tag to fetch (defaults to every tracked player)")
+	snapshot := fs.Bool("snapshot", true, "also record a snapshot of each player's profile")
+	fs.Parse(args)
+
+	tags := cfg.TrackedTags()
+	if *tag != "" {
+		normalized, err := types.NormalizeTag(*tag)
+		if err != nil {
+			return err
+		}
+		tags = []string{normalized}
+	}
+	if len(tags) == 0 {
+		return errors.New("no players tracked: set PLAYERTAG in environment variables or run \"loggob players add\"")
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	failed := 0
+	for _, t := range tags {
+		err := fetchTracked(ctx, client, s, t, *snapshot)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if len(tags) == 1 {
+				return err
+			}
+			// One player failing shouldn't stop the rest from being fetched
+			slog.Error("Fetch failed", logging.KeyTag, t, "err", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to fetch %d of %d players", failed, len(tags))
+	}
+
+	return nil
+}
+
+// fetchTracked fetches tag's battle log and, if snapshot is set, its profile.
+func fetchTracked(ctx context.Context, client *api.Client, s storage.Store, tag string, snapshot bool) error {
+	if _, err := fetchPlayer(ctx, client, s, tag); err != nil {
+		return err
+	}
+	if snapshot {
+		return snapshotPlayer(ctx, client, s, tag)
+	}
+	return nil
+}
+
+// snapshotPlayer fetches tag's profile and records it as a snapshot.
+// Profiles unchanged since the last fetch are not recorded again.
+func snapshotPlayer(ctx context.Context, client *api.Client, s storage.Store, tag string) error {
+	logger := logging.FromContext(ctx).With(logging.KeyTag, tag)
+	ctx = logging.NewContext(ctx, logger)
+
+	profile, src, err := client.Player(ctx, tag)
+	if err != nil {
+		return fmt.Errorf("failed to fetch profile of %s: %w", tag, err)
+	}
+	if src.Cached() {
+		logger.Info("Profile unchanged")
+		return nil
+	}
+
+	if err := s.SavePlayerSnapshot(profile, time.Now()); err != nil {
+		return fmt.Errorf("failed to save profile snapshot of %s: %w", tag, err)
+	}
+	logger.Info("Saved profile snapshot", "trophies", profile.Trophies, "best_trophies", profile.BestTrophies)
+
+	return nil
+}
+
+// fetchResult describes what one fetch of a player's battle log did.
+type fetchResult struct {
+	storage.InsertResult
+	Fetched   int
+	APIStatus int    // HTTP status of the battle log request; 0 if none was received or made
+	Latest    string // BattleTime of the newest battle in the log, "" if the log was unchanged or empty
+	Gap       bool   // battles may have been missed since the previous fetch
+}
+
+// fetchPlayer fetches tag's battle log and saves it, recording the attempt in the ingestion run log.
+func fetchPlayer(ctx context.Context, client *api.Client, s storage.Store, tag string) (fetchResult, error) {
+	run := storage.IngestionRun{PlayerTag: tag, StartedAt: time.Now()}
+
+	id, err := s.StartRun(tag, run.StartedAt)
+	if err != nil {
+		return fetchResult{}, fmt.Errorf("failed to record ingestion run for %s: %w", tag, err)
+	}
+	run.ID = id
+
+	logger := logging.FromContext(ctx).With(logging.KeyTag, tag, logging.KeyRunID, id)
+	ctx = logging.NewContext(ctx, logger)
+
+	out, err := ingestBattleLog(ctx, client, s, tag)
+
+	run.FinishedAt = time.Now()
+	run.APIStatus = out.APIStatus
+	run.Fetched = out.Fetched
+	run.Inserted, run.Duplicates, run.Skipped = out.Inserted, out.Duplicates, out.Skipped
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.LatestBattle = out.Latest
+	}
+	if ferr := s.FinishRun(run); ferr != nil {
+		logger.Error("Failed to record outcome of ingestion run", "err", ferr)
+	}
+
+	return out, err
+}
+
+// ingestBattleLog fetches tag's battle log and saves it.
+// The battles are written in one transaction, so an interrupted run never leaves one half-written.
+// When a full log is newer than anything seen before, the gap is recorded; a shorter
+// log holds the player's whole history, so nothing can have rotated out of it.
+func ingestBattleLog(ctx context.Context, client *api.Client, s storage.Store, tag string) (fetchResult, error) {
+	var out fetchResult
+	logger := logging.FromContext(ctx)
+
+	battleLog, src, err := client.BattleLog(ctx, tag)
+	out.APIStatus = apiStatus(src, err)
+	if err != nil {
+		return out, fmt.Errorf("failed to fetch battles for %s: %w", tag, err)
+	}
+	if src.Cached() {
+		logger.Info("No new battles", "source", src)
+		return out, nil
+	}
+
+	logger.Info("Fetched battle log", "battles", len(battleLog), "key", client.ActiveKey().Label())
+	out.Fetched = len(battleLog)
+	if len(battleLog) == 0 {
+		return out, nil
+	}
+
+	// Battles left out by the ingestion filter are never stored, so the previous
+	// runs remember the newest battle they saw. Stored battles count too, as they
+	// may have been imported rather than fetched.
+	newest, err := s.NewestBattleTime(tag)
+	if err != nil {
+		return out, fmt.Errorf("failed to read stored battles for %s: %w", tag, err)
+	}
+	seen, err := s.LatestFetchedBattle(tag)
+	if err != nil {
+		return out, fmt.Errorf("failed to read ingestion runs for %s: %w", tag, err)
+	}
+	newest = max(newest, seen)
+
+	oldest := battleLog[0].BattleTime
+	for _, b := range battleLog {
+		// BattleTime sorts chronologically as a string
+		if b.BattleTime < oldest {
+			oldest = b.BattleTime
+		}
+		if b.BattleTime > out.Latest {
+			out.Latest = b.BattleTime
+		}
+	}
+
+	res, err := s.InsertBattles(ctx, battleLog)
+	if err != nil {
+		return out, fmt.Errorf("failed to save battles for %s: %w", tag, err)
+	}
+	out.InsertResult = res
+
+	logger.Info("Saved battles", "inserted", res.Inserted, "duplicates", res.Duplicates, "skipped", res.Skipped)
+
+	// A player never fetched before has no history to have a hole in, and battles
+	// only rotate out once the log is full
+	if newest != "" && oldest > newest && len(battleLog) >= api.BattleLogSize {
+		out.Gap = true
+		gap := storage.IngestionGap{PlayerTag: tag, After: newest, Before: oldest, DetectedAt: time.Now()}
+		if err := s.RecordGap(gap); err != nil {
+			return out, fmt.Errorf("failed to record ingestion gap for %s: %w", tag, err)
+		}
+		logger.Warn("Gap in battle history, battles may be missing", "after", newest, "before", oldest)
+	}
+
+	return out, nil
+}
+
+// apiStatus returns the HTTP status a request ending in err received, or 0 if it got no
+// response or the data came from the cache without a request.
+func apiStatus(src api.Source, err error) int {
+	var apiErr *api.Error
+	switch {
+	case err == nil && src == api.FromCache:
+		return 0
+	case err == nil && src == api.Revalidated:
+		return http.StatusNotModified
+	case err == nil:
+		return http.StatusOK
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode
+	}
+	return 0
+}
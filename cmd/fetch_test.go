@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/fakeapi"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+func newTestStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := storage.NewStorage(db)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return s
+}
+
+func battleAt(battleTime, battleType string) types.Battle {
+	return types.Battle{
+		BattleTime: battleTime,
+		BattleType: battleType,
+		GameMode:   types.GameMode{ID: storage.LadderGameModeID},
+		Team:       []types.Player{{Tag: "#2PP", Crowns: 1}},
+		Opponent:   []types.Player{{Tag: "#8QQ"}},
+	}
+}
+
+// fullLog returns a battle log of api.BattleLogSize battles a minute apart
+// starting at 11:00, newest first, as the API serves it once a player's log is full.
+func fullLog(battleType string) []types.Battle {
+	log := make([]types.Battle, api.BattleLogSize)
+	for i := range log {
+		log[i] = battleAt(fmt.Sprintf("20251012T11%02d00.000Z", len(log)-1-i), battleType)
+	}
+	return log
+}
+
+// TestFetchGapsWithIngestionFilter checks that battles left out by the ingestion
+// filter still count as seen, so they do not look like a gap on the next fetch.
+func TestFetchGapsWithIngestionFilter(t *testing.T) {
+	fake := fakeapi.New()
+	srv := fake.Start()
+	defer srv.Close()
+
+	client := api.New(srv.URL, "token")
+	s := newTestStorage(t)
+	s.Ingest = storage.LadderBattles
+	ctx := context.Background()
+
+	polls := [][]types.Battle{
+		{battleAt("20251012T100500.000Z", "friendly"), battleAt("20251012T100000.000Z", "PvP")},
+		{battleAt("20251012T101000.000Z", "friendly"), battleAt("20251012T100500.000Z", "friendly")},
+		fullLog("PvP"),
+	}
+	wantGap := []bool{false, false, true}
+
+	for i, log := range polls {
+		fake.SetBattleLog("#2PP", log)
+		res, err := fetchPlayer(ctx, client, s, "#2PP")
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if res.Gap != wantGap[i] {
+			t.Errorf("poll %d: gap = %v, want %v", i, res.Gap, wantGap[i])
+		}
+	}
+
+	gaps, err := s.IngestionGaps("#2PP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 1 || gaps[0].After != "20251012T101000.000Z" || gaps[0].Before != "20251012T110000.000Z" {
+		t.Errorf("gaps = %+v, want one between the last friendly and the new ladder battles", gaps)
+	}
+}
+
+// TestFetchNoGapForShortLog checks that a log shorter than the API's cap is not
+// treated as a gap, since no battle can have rotated out of it yet.
+func TestFetchNoGapForShortLog(t *testing.T) {
+	fake := fakeapi.New()
+	srv := fake.Start()
+	defer srv.Close()
+
+	client := api.New(srv.URL, "token")
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	polls := [][]types.Battle{
+		{battleAt("20251012T100000.000Z", "PvP")},
+		{battleAt("20251012T120000.000Z", "PvP"), battleAt("20251012T110000.000Z", "PvP")},
+	}
+	for i, log := range polls {
+		fake.SetBattleLog("#2PP", log)
+		res, err := fetchPlayer(ctx, client, s, "#2PP")
+		if err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+		if res.Gap {
+			t.Errorf("poll %d: gap recorded for a %d-battle log", i, len(log))
+		}
+	}
+
+	gaps, err := s.IngestionGaps("#2PP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 0 {
+		t.Errorf("gaps = %+v, want none", gaps)
+	}
+}
+
+// TestFetchRunStatus checks the API status recorded for each way a battle log can be served.
+func TestFetchRunStatus(t *testing.T) {
+	maxAge := 0
+	log := []types.Battle{battleAt("20251012T100000.000Z", "PvP")}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := json.Marshal(log)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	client := api.New(srv.URL, "token", api.WithCache(t.TempDir()))
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	polls := []struct {
+		maxAge int
+		status string
+	}{
+		{0, "200"},     // nothing cached yet
+		{60, "304"},    // cached entry revalidated, now fresh for a minute
+		{60, "cached"}, // fresh entry used without a request
+	}
+	for i, p := range polls {
+		maxAge = p.maxAge
+		if _, err := fetchPlayer(ctx, client, s, "#2PP"); err != nil {
+			t.Fatalf("poll %d: %v", i, err)
+		}
+	}
+
+	runs, err := s.IngestionRuns(storage.RunFilter{PlayerTag: "#2PP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != len(polls) {
+		t.Fatalf("recorded %d runs, want %d", len(runs), len(polls))
+	}
+	for i, p := range polls {
+		r := runs[len(runs)-1-i]
+		if got := runStatus(r); got != p.status {
+			t.Errorf("poll %d: status %q (api_status %d), want %q", i, got, r.APIStatus, p.status)
+		}
+	}
+}
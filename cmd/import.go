@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/importer"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// maxProblemsShown is how many invalid records of a file are listed before the rest are summarized.
+const maxProblemsShown = 10
+
+// runImport implements "loggob import": load battles from exported files.
+func runImport(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	formatFlag := fs.String("format", "auto", "file format: auto, json, ndjson or csv")
+	dryRun := fs.Bool("dry-run", false, "validate the files without storing anything")
+	fs.Parse(args)
+
+	format, err := importer.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("usage: loggob import [-format auto|json|ndjson|csv] [-dry-run] <file>... (\"-\" reads standard input)")
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	lookup, err := cardLookup(s)
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, name := range fs.Args() {
+		n, err := importFile(ctx, s, name, format, lookup, *dryRun)
+		if err != nil {
+			return err
+		}
+		invalid += n
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid records were not imported", invalid)
+	}
+	return nil
+}
+
+// importFile imports one file and returns how many of its records were invalid.
+// A file's battles are stored in one transaction, so a failed import stores none of them.
+func importFile(ctx context.Context, s storage.Store, name string, format importer.Format, lookup importer.CardLookup, dryRun bool) (int, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	battles, problems, err := importer.Read(r, name, format, lookup)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	for i, p := range problems {
+		if i == maxProblemsShown {
+			slog.Warn("More invalid records not shown", "file", name, "count", len(problems)-maxProblemsShown)
+			break
+		}
+		slog.Warn("Invalid record", "file", name, "record", p.Where, "err", p.Err)
+	}
+
+	if dryRun {
+		slog.Info("Checked import file (dry run, nothing stored)", "file", name, "valid", len(battles), "invalid", len(problems))
+		return len(problems), nil
+	}
+
+	res, err := s.InsertBattles(ctx, battles)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save battles from %s: %w", name, err)
+	}
+
+	slog.Info("Imported battles", "file", name, "inserted", res.Inserted, "duplicates", res.Duplicates,
+		"skipped", res.Skipped, "invalid", len(problems))
+
+	return len(problems), nil
+}
+
+// cardLookup resolves card names and IDs in CSV imports, preferring the synced card
+// catalog for metadata and falling back to cards seen in stored battles.
+func cardLookup(s storage.Store) (importer.CardLookup, error) {
+	catalog, err := s.GetCardCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load card catalog: %w", err)
+	}
+
+	byID := make(map[int32]types.Card, len(catalog))
+	byName := make(map[string]types.Card, len(catalog))
+	for _, c := range catalog {
+		byID[c.ID] = c
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	return func(ref string) (types.Card, error) {
+		if id, err := strconv.ParseInt(ref, 10, 32); err == nil {
+			if c, ok := byID[int32(id)]; ok {
+				return c, nil
+			}
+			return types.Card{}, fmt.Errorf("unknown card ID %d (run \"loggob cards sync\" first)", id)
+		}
+		if c, ok := byName[strings.ToLower(ref)]; ok {
+			return c, nil
+		}
+
+		id, err := s.FindCard(ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Card{}, fmt.Errorf("unknown card %q (run \"loggob cards sync\" first)", ref)
+		}
+		if err != nil {
+			return types.Card{}, err
+		}
+		return types.Card{ID: id, Name: ref}, nil
+	}, nil
+}
@@ -1,63 +1,216 @@
+// Command loggob fetches Clash Royale data from the API and stores it in the local database.
+//
+// Usage:
+//
+//	loggob [-profile name] [command] [flags]
+//
+// Commands:
+//
+//	fetch       fetch every tracked player's battle log (the default)
+//	watch       poll battle logs continuously, recording gaps in the history
+//	import      load battles from exported JSON, NDJSON or CSV files
+//	players     add, remove or list tracked players
+//	runs        list recent ingestion runs and gaps in the history
+//	battles     list stored battles matching filters
+//	clan sync   sync the configured clan, its river races and every member's battle log
+//	cards sync  refresh the global card catalog
+//	db migrate  apply pending schema migrations
+//	db status   list schema migrations and whether they are applied
+//	db reparse  rebuild battles from their archived raw JSON
 package main
 
 import (
-	"database/sql"
-	"log"
-	"net/url"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/elliot727/log-gob/internal/api"
 	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
 	"github.com/elliot727/log-gob/internal/storage"
-	"github.com/elliot727/log-gob/internal/types"
-	_ "github.com/glebarez/go-sqlite"
 )
 
+const usage = `Usage: loggob [-profile official|proxy|local] [command] [flags]
+
+Commands:
+  fetch       fetch every tracked player's battle log (default)
+  watch       poll battle logs continuously, recording gaps in the history
+  import      load battles from exported JSON, NDJSON or CSV files
+  players     add, remove or list tracked players
+  runs        list recent ingestion runs and gaps in the history
+  battles     list stored battles matching filters
+  clan sync   sync the configured clan, its river races and every member's battle log
+  cards sync  refresh the global card catalog
+  db migrate  apply pending schema migrations
+  db status   list schema migrations and whether they are applied
+  db reparse  rebuild battles from their archived raw JSON
+
+The -profile flag (or API_PROFILE) selects the API endpoint: the official API,
+the RoyaleAPI proxy for machines without a fixed IP, or a local fake server.
+
+Run "loggob <command> -h" for command flags.
+`
+
 func main() {
+	// Cancel in-flight requests on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal(fmt.Errorf("failed to load configuration: %w", err))
 	}
 
-	if cfg.APIKey == "" {
-		log.Fatal("APIKEY not set in environment variables")
+	closeLog, err := logging.Setup(cfg.LogFormat, cfg.LogLevel, cfg.LogFile, os.Stderr)
+	if err != nil {
+		fatal(err)
 	}
+	defer closeLog()
+	logFile = cfg.LogFile
+
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.StringVar(&cfg.APIProfile, "profile", cfg.APIProfile, "API endpoint profile")
+	flag.Parse()
 
-	if cfg.PlayerTag == "" {
-		log.Fatal("PLAYERTAG not set in environment variables")
+	cmd, args := "fetch", flag.Args()
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
 	}
 
-	if len(cfg.PlayerTag) < 2 || cfg.PlayerTag[0] != '#' {
-		log.Fatalf("invalid tag: %s", cfg.PlayerTag)
+	switch cmd {
+	case "fetch":
+		err = runFetch(ctx, cfg, args)
+	case "watch":
+		err = runWatch(ctx, cfg, args)
+	case "import":
+		err = runImport(ctx, cfg, args)
+	case "players":
+		err = runPlayers(ctx, cfg, args)
+	case "runs":
+		err = runRuns(ctx, cfg, args)
+	case "battles":
+		err = runBattles(ctx, cfg, args)
+	case "clan":
+		err = runClan(ctx, cfg, args)
+	case "cards":
+		err = runCards(ctx, cfg, args)
+	case "db":
+		err = runDB(ctx, cfg, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
 	}
 
-	db, err := sql.Open("sqlite", cfg.DBPath)
 	if err != nil {
-		log.Fatal("Failed to open database:", err)
+		fatal(err)
 	}
-	defer db.Close()
+}
 
-	s := storage.NewStorage(db)
+// logFile is LOG_FILE, once logging is set up.
+var logFile string
 
-	if err := s.Init(); err != nil {
-		log.Fatal("Failed to initialize storage:", err)
+// fatal logs err, with an actionable hint for API errors, and exits. When logs
+// go to a file the error is printed to stderr too, so it is never silent.
+func fatal(err error) {
+	if errors.Is(err, context.Canceled) {
+		slog.Error("Interrupted")
+		os.Exit(1)
+	}
+
+	attrs := []any{"err", err}
+	hint := api.Hint(err)
+	if hint != "" {
+		attrs = append(attrs, "hint", hint)
+	}
+	slog.Error("Command failed", attrs...)
+
+	if logFile != "" {
+		fmt.Fprintf(os.Stderr, "loggob: %v\n", err)
+		if hint != "" {
+			fmt.Fprintln(os.Stderr, hint)
+		}
+	}
+	os.Exit(1)
+}
+
+// newClient creates an API client from the configuration.
+// With API_REPLAY_DIR set, responses come from recorded fixtures and no API key is needed.
+func newClient(cfg *config.Config) (*api.Client, error) {
+	profile, ok := api.LookupProfile(cfg.APIProfile)
+	if !ok {
+		return nil, fmt.Errorf("unknown API profile %q (available: %s)", cfg.APIProfile, strings.Join(api.ProfileNames(), ", "))
+	}
+	if cfg.APIBaseURL != "" {
+		profile.BaseURL = cfg.APIBaseURL
+	}
+	if len(cfg.APIHeaders) > 0 {
+		headers := make(map[string]string, len(profile.Headers)+len(cfg.APIHeaders))
+		for k, v := range profile.Headers {
+			headers[k] = v
+		}
+		for k, v := range cfg.APIHeaders {
+			headers[k] = v
+		}
+		profile.Headers = headers
 	}
 
-	client := api.New(cfg.APIBaseURL, cfg.APIKey)
+	opts := []api.Option{api.WithProfile(profile)}
+	needsKey := profile.Auth != api.AuthNone
 
-	var battleLog []types.Battle
+	switch {
+	case cfg.APIReplayDir != "":
+		opts = append(opts, api.WithTransport(&api.ReplayTransport{Dir: cfg.APIReplayDir}))
+	case needsKey && len(cfg.APIKeys) == 0:
+		return nil, errors.New("APIKEY not set in environment variables")
+	case cfg.APIRecordDir != "":
+		opts = append(opts, api.WithTransport(&api.RecordingTransport{Dir: cfg.APIRecordDir}))
+	}
 
-	escaped := url.PathEscape(cfg.PlayerTag)
-	if err := client.Get("/v1/players/"+escaped+"/battlelog", &battleLog); err != nil {
-		log.Fatalf("failed to fetch battles: %v", err)
+	keys := make([]api.Key, len(cfg.APIKeys))
+	for i, k := range cfg.APIKeys {
+		keys[i] = api.Key{Token: k.Token, IP: k.IP}
 	}
 
-	log.Printf("Fetched %d battles for %s", len(battleLog), cfg.PlayerTag)
+	opts = append(opts,
+		api.WithKeys(keys...),
+		api.WithPreferredIP(cfg.APIIP),
+		api.WithCache(cfg.APICacheDir),
+	)
+	return api.New(profile.BaseURL, "", opts...), nil
+}
 
-	for _, b := range battleLog {
-		if err := s.InsertBattle(&b); err != nil {
-			log.Printf("storage error: %v", err)
-			continue
-		}
-		log.Printf("Saved battle: %s", b.BattleTime)
+// openStorage opens the database and applies pending migrations. The returned function closes it.
+func openStorage(cfg *config.Config) (storage.Store, func(), error) {
+	s, closeDB, err := openDB(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
+
+	if err := s.Init(); err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	return s, closeDB, nil
+}
+
+// openDB opens the database without touching its schema. The returned function closes it.
+// DB_PATH selects the backend: a postgres:// URL for PostgreSQL, otherwise a SQLite file.
+func openDB(cfg *config.Config) (storage.Store, func(), error) {
+	s, err := storage.Open(cfg.DBPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Ingest = storage.BattleFilter{Types: cfg.IngestBattleTypes, GameModeIDs: cfg.IngestGameModes}
+
+	return s, func() { s.Close() }, nil
 }
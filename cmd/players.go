@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+const playersUsage = "usage: loggob players add|remove|list"
+
+// runPlayers implements "loggob players": manage the tracked players file.
+func runPlayers(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(playersUsage)
+	}
+
+	switch args[0] {
+	case "add":
+		return runPlayersAdd(ctx, cfg, args[1:])
+	case "remove":
+		return runPlayersRemove(cfg, args[1:])
+	case "list":
+		return runPlayersList(cfg)
+	default:
+		return errors.New(playersUsage)
+	}
+}
+
+// runPlayersAdd implements "loggob players add": track more players.
+// Each tag is looked up in the API first, so typos are caught and the player's name recorded.
+func runPlayersAdd(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("players add", flag.ExitOnError)
+	check := fs.Bool("check", true, "look each player up in the API before adding them")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return errors.New("usage: loggob players add [-check=false] <tag>...")
+	}
+
+	players := cfg.Players
+	var added []config.TrackedPlayer
+
+	for _, arg := range fs.Args() {
+		tag, err := types.NormalizeTag(arg)
+		if err != nil {
+			return err
+		}
+		if tag == cfg.PlayerTag || config.FindPlayer(players, tag) >= 0 {
+			slog.Info("Player already tracked", logging.KeyTag, tag)
+			continue
+		}
+		added = append(added, config.TrackedPlayer{Tag: tag})
+		players = append(players, config.TrackedPlayer{Tag: tag})
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	if *check {
+		client, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+		for _, p := range added {
+			profile, _, err := client.Player(ctx, p.Tag)
+			if err != nil {
+				return fmt.Errorf("failed to look up %s: %w", p.Tag, err)
+			}
+			players[config.FindPlayer(players, p.Tag)].Name = profile.Name
+		}
+	}
+
+	if err := config.SavePlayers(cfg.PlayersFile, players); err != nil {
+		return fmt.Errorf("failed to save %s: %w", cfg.PlayersFile, err)
+	}
+	for _, p := range added {
+		p = players[config.FindPlayer(players, p.Tag)]
+		slog.Info("Tracking player", logging.KeyTag, p.Tag, "name", p.Name)
+	}
+
+	return nil
+}
+
+// runPlayersRemove implements "loggob players remove": stop tracking players.
+// Their stored battles are kept.
+func runPlayersRemove(cfg *config.Config, tags []string) error {
+	if len(tags) == 0 {
+		return errors.New("usage: loggob players remove <tag>...")
+	}
+
+	players := cfg.Players
+	var removed []string
+	for _, arg := range tags {
+		tag, err := types.NormalizeTag(arg)
+		if err != nil {
+			return err
+		}
+		if tag == cfg.PlayerTag {
+			return fmt.Errorf("%s is PLAYERTAG; change it in the environment instead", tag)
+		}
+		i := config.FindPlayer(players, tag)
+		if i < 0 {
+			return fmt.Errorf("%s is not tracked", tag)
+		}
+		players = append(players[:i:i], players[i+1:]...)
+		removed = append(removed, tag)
+	}
+
+	if err := config.SavePlayers(cfg.PlayersFile, players); err != nil {
+		return fmt.Errorf("failed to save %s: %w", cfg.PlayersFile, err)
+	}
+	for _, tag := range removed {
+		slog.Info("Stopped tracking player; its stored battles are kept", logging.KeyTag, tag)
+	}
+
+	return nil
+}
+
+// runPlayersList implements "loggob players list": show tracked players and their stored history.
+func runPlayersList(cfg *config.Config) error {
+	tags := cfg.TrackedTags()
+	if len(tags) == 0 {
+		fmt.Println("No players tracked")
+		return nil
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TAG\tNAME\tBATTLES\tLAST BATTLE\t")
+
+	for _, tag := range tags {
+		name := ""
+		if i := config.FindPlayer(cfg.Players, tag); i >= 0 {
+			name = cfg.Players[i].Name
+		}
+		if tag == cfg.PlayerTag {
+			name = strings.TrimSpace(name + " (PLAYERTAG)")
+		}
+
+		count, err := s.CountBattles(storage.BattleQuery{PlayerTag: tag, Filter: storage.AllBattles})
+		if err != nil {
+			return fmt.Errorf("failed to count battles of %s: %w", tag, err)
+		}
+		last, err := s.NewestBattleTime(tag)
+		if err != nil {
+			return fmt.Errorf("failed to read battles of %s: %w", tag, err)
+		}
+		if last == "" {
+			last = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t\n", tag, name, count, last)
+	}
+
+	return w.Flush()
+}
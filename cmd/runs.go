@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// runRuns implements "loggob runs": list recent ingestion runs and recorded gaps.
+func runRuns(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	tag := fs.String("tag", "", "only runs for this player")
+	failed := fs.Bool("failed", false, "only runs that failed or never finished")
+	limit := fs.Int("limit", 20, "maximum number of runs to list (0 for all)")
+	fs.Parse(args)
+
+	filter := storage.RunFilter{Failed: *failed, Limit: *limit}
+	if *tag != "" {
+		normalized, err := types.NormalizeTag(*tag)
+		if err != nil {
+			return err
+		}
+		filter.PlayerTag = normalized
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	runs, err := s.IngestionRuns(filter)
+	if err != nil {
+		return fmt.Errorf("failed to load ingestion runs: %w", err)
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No ingestion runs recorded")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTARTED\tDURATION\tTAG\tSTATUS\tFETCHED\tNEW\tDUPLICATE\tFILTERED\tERROR\t")
+		for _, r := range runs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t\n",
+				r.ID, r.StartedAt.Local().Format("2006-01-02 15:04:05"), runDuration(r), r.PlayerTag,
+				runStatus(r), r.Fetched, r.Inserted, r.Duplicates, r.Skipped, r.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	gaps, err := s.IngestionGaps(filter.PlayerTag)
+	if err != nil {
+		return fmt.Errorf("failed to load ingestion gaps: %w", err)
+	}
+	if len(gaps) > 0 {
+		fmt.Printf("\n%d gaps where battles may be missing:\n", len(gaps))
+		for _, g := range gaps {
+			fmt.Printf("  %s  %s - %s\n", g.PlayerTag, g.After, g.Before)
+		}
+	}
+
+	return nil
+}
+
+// runDuration formats how long a run took, or "-" if it never finished.
+func runDuration(r storage.IngestionRun) string {
+	if r.FinishedAt.IsZero() {
+		return "-"
+	}
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond).String()
+}
+
+// runStatus summarizes a run's outcome: its API status, or why it has none.
+func runStatus(r storage.IngestionRun) string {
+	switch {
+	case r.FinishedAt.IsZero():
+		return "unfinished"
+	case r.Cached():
+		return "cached"
+	case r.APIStatus == 0:
+		return "no response"
+	}
+	return fmt.Sprint(r.APIStatus)
+}
@@ -2,48 +2,67 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
 	"github.com/elliot727/log-gob/internal/storage"
 	"github.com/elliot727/log-gob/internal/ui"
-	_ "github.com/glebarez/go-sqlite"
 )
 
+// defaultLogFile receives the TUI's logs when LOG_FILE is not set, since the
+// terminal belongs to the interface.
+const defaultLogFile = "loggob.log"
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to load configuration:", err)
+		fatal(fmt.Errorf("failed to load configuration: %w", err))
 	}
 
-	if cfg.PlayerTag == "" {
-		log.Fatal("PLAYERTAG environment variable not set. Please set PLAYERTAG in your .env file with your Clash Royale player tag (e.g., #ABC123)")
+	logFile := cfg.LogFile
+	if logFile == "" {
+		logFile = defaultLogFile
+	}
+	closeLog, err := logging.Setup(cfg.LogFormat, cfg.LogLevel, logFile, os.Stderr)
+	if err != nil {
+		fatal(err)
 	}
+	defer closeLog()
 
-	// Validate player tag format - should start with # after our potential addition and have valid characters
-	if len(cfg.PlayerTag) < 2 {
-		log.Fatalf("Invalid player tag format: %s. Player tag should not be empty", cfg.PlayerTag)
+	players := cfg.TrackedTags()
+	if len(players) == 0 {
+		fatal(errors.New("PLAYERTAG environment variable not set. Please set PLAYERTAG in your .env file with your Clash Royale player tag (e.g., #ABC123), or track players with \"loggob players add\""))
 	}
 
-	db, err := sql.Open("sqlite", cfg.DBPath)
+	s, err := storage.Open(cfg.DBPath)
 	if err != nil {
-		log.Fatal("Failed to open database:", err)
+		fatal(err)
 	}
-	defer db.Close()
-
-	s := storage.NewStorage(db)
+	defer s.Close()
 
 	// Initialize storage (create tables if they don't exist)
 	err = s.Init()
 	if err != nil {
-		log.Fatal("Failed to initialize storage:", err)
+		fatal(fmt.Errorf("failed to initialize storage: %w", err))
 	}
 
-	p := tea.NewProgram(ui.InitialModel(s, cfg.PlayerTag))
+	slog.Info("Starting TUI", "players", len(players))
+
+	p := tea.NewProgram(ui.InitialModel(s, players))
 	_, err = p.Run()
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 }
+
+// fatal logs err and prints it to stderr, which the log file would otherwise hide, then exits.
+func fatal(err error) {
+	slog.Error("TUI failed", "err", err)
+	fmt.Fprintf(os.Stderr, "loggob-tui: %v\n", err)
+	os.Exit(1)
+}
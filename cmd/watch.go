@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/config"
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// pollSchedule decides how long to wait between fetches of one player.
+// Players who are actively battling are polled every Min; each idle poll doubles
+// the wait, up to Max.
+type pollSchedule struct {
+	Min, Max time.Duration
+	// IdleAfter is how long after their last battle a player counts as idle.
+	IdleAfter time.Duration
+}
+
+// next returns the wait before the following poll, given the previous wait and
+// what the last fetch found.
+func (p pollSchedule) next(prev time.Duration, res fetchResult, now time.Time) time.Duration {
+	if res.Inserted > 0 || p.active(res.Latest, now) {
+		return p.Min
+	}
+	if prev < p.Min {
+		return p.Min
+	}
+	return min(prev*2, p.Max)
+}
+
+// active reports whether battleTime is within IdleAfter of now.
+func (p pollSchedule) active(battleTime string, now time.Time) bool {
+	if battleTime == "" {
+		return false
+	}
+	t, err := time.Parse(types.BattleTimeLayout, battleTime)
+	return err == nil && now.Sub(t) < p.IdleAfter
+}
+
+// watchedPlayer is the polling state of one tag.
+type watchedPlayer struct {
+	tag  string
+	wait time.Duration
+	due  time.Time
+}
+
+// runWatch implements "loggob watch": poll players' battle logs until interrupted.
+func runWatch(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	tags := fs.String("tag", strings.Join(cfg.TrackedTags(), ","), "comma-separated player tags to watch (defaults to every tracked player)")
+	minWait := fs.Duration("min", 2*time.Minute, "poll interval while a player is battling")
+	maxWait := fs.Duration("max", 30*time.Minute, "longest poll interval for an idle player")
+	idleAfter := fs.Duration("idle", 20*time.Minute, "time since the last battle after which a player is idle")
+	snapshot := fs.Bool("snapshot", true, "record a profile snapshot whenever new battles are saved")
+	fs.Parse(args)
+
+	if *minWait <= 0 || *maxWait < *minWait {
+		return errors.New("-min must be positive and no greater than -max")
+	}
+
+	var players []string
+	for _, t := range splitList(*tags) {
+		tag, err := types.NormalizeTag(t)
+		if err != nil {
+			return err
+		}
+		players = append(players, tag)
+	}
+	if len(players) == 0 {
+		return errors.New("no players tracked: set PLAYERTAG in environment variables or run \"loggob players add\"")
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	s, closeDB, err := openStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	sched := pollSchedule{Min: *minWait, Max: *maxWait, IdleAfter: *idleAfter}
+	return watch(ctx, client, s, sched, players, *snapshot)
+}
+
+// watch polls each tag on its own schedule until ctx is cancelled, which is a clean shutdown.
+// Fetch errors are logged and retried later rather than stopping the other players.
+func watch(ctx context.Context, client *api.Client, s storage.Store, sched pollSchedule, tags []string, snapshot bool) error {
+	now := time.Now()
+	players := make([]*watchedPlayer, len(tags))
+	for i, tag := range tags {
+		players[i] = &watchedPlayer{tag: tag, due: now}
+	}
+
+	slog.Info("Watching players", "players", len(players), "min_interval", sched.Min, "max_interval", sched.Max)
+
+	for {
+		p := players[0]
+		for _, q := range players[1:] {
+			if q.due.Before(p.due) {
+				p = q
+			}
+		}
+
+		timer := time.NewTimer(time.Until(p.due))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Stopping watch")
+			return nil
+		case <-timer.C:
+		}
+
+		res, err := fetchPlayer(ctx, client, s, p.tag)
+		if ctx.Err() != nil {
+			slog.Info("Stopping watch")
+			return nil
+		}
+		if err != nil {
+			slog.Error("Fetch failed", logging.KeyTag, p.tag, "err", err)
+		} else if snapshot && res.Inserted > 0 {
+			if err := snapshotPlayer(ctx, client, s, p.tag); err != nil && ctx.Err() == nil {
+				slog.Error("Snapshot failed", logging.KeyTag, p.tag, "err", err)
+			}
+		}
+
+		p.wait = sched.next(p.wait, res, time.Now())
+		p.due = time.Now().Add(p.wait)
+		slog.Info("Scheduled next poll", logging.KeyTag, p.tag, "wait", p.wait)
+	}
+}
@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/storage"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// benchmarkBattles is how many battles BenchmarkCompute stores. Seeding them takes
+// a few minutes, so run the benchmark with a longer -timeout if needed.
+const benchmarkBattles = 50_000
+
+// syntheticBattles returns n Ladder battles of tag against varied opponents and
+// decks, one every ten minutes, with reproducible outcomes.
+func syntheticBattles(tag string, n int) []types.Battle {
+	rng := rand.New(rand.NewSource(1))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	deck := func() []types.Card {
+		cards := make([]types.Card, 8)
+		for i, id := range rng.Perm(60)[:8] {
+			cards[i] = types.Card{ID: 26000000 + int32(id), Name: "Card", Level: 11 + int32(rng.Intn(4)), MaxLevel: 14,
+				Rarity: "common", ElixirCost: 1 + int32(id%8)}
+		}
+		return cards
+	}
+
+	battles := make([]types.Battle, n)
+	trophies := int32(5000)
+	for i := range battles {
+		crowns, oppCrowns := int32(rng.Intn(4)), int32(rng.Intn(4))
+		change := int32(0)
+		switch {
+		case crowns > oppCrowns:
+			change = 30
+		case crowns < oppCrowns:
+			change = -30
+		}
+
+		battles[i] = types.Battle{
+			BattleTime: start.Add(time.Duration(i) * 10 * time.Minute).Format(types.BattleTimeLayout),
+			BattleType: "PvP",
+			Arena:      types.Arena{ID: 54000015 + int32(i%5), Name: "Arena"},
+			GameMode:   types.GameMode{ID: storage.LadderGameModeID, Name: "Ladder"},
+			Team: []types.Player{{Tag: tag, Name: "Me", StartingTrophies: trophies, TrophyChange: change,
+				Crowns: crowns, ElixirLeaked: rng.Float64() * 3, Cards: deck()}},
+			Opponent: []types.Player{{Tag: fmt.Sprintf("#OPP%d", i%500),
+				Name: "Them", StartingTrophies: trophies + int32(rng.Intn(100)-50), TrophyChange: -change,
+				Crowns: oppCrowns, Cards: deck()}},
+		}
+		trophies += change
+	}
+	return battles
+}
+
+func BenchmarkCompute(b *testing.B) {
+	db, err := sql.Open("sqlite", filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	s := storage.NewStorage(db)
+	if err := s.Init(); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := s.InsertBattles(context.Background(), syntheticBattles("#2PP", benchmarkBattles)); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		a, err := Compute(s, "#2PP", storage.LadderBattles, 6000)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if a.Overall.TotalBattles != benchmarkBattles {
+			b.Fatalf("computed %d battles, want %d", a.Overall.TotalBattles, benchmarkBattles)
+		}
+	}
+}
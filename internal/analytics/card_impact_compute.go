@@ -49,6 +49,13 @@ func computeCardImpact(battles []types.Battle, myTag string) []CardImpact {
 				levelPerf.Wins++
 			}
 			stat.BattlesAtLevel[int(card.Level)] = levelPerf
+
+			if card.Evolved() {
+				stat.Evolved.Battles++
+				if won {
+					stat.Evolved.Wins++
+				}
+			}
 		}
 	}
 
@@ -61,6 +68,9 @@ func computeCardImpact(battles []types.Battle, myTag string) []CardImpact {
 				stat.BattlesAtLevel[level] = perf
 			}
 		}
+		if stat.Evolved.Battles > 0 {
+			stat.Evolved.WinRate = float64(stat.Evolved.Wins) / float64(stat.Evolved.Battles) * 100
+		}
 		// Compute stats since last upgrade
 		stat.SinceLastUpgrade = calculateSinceLastUpgrade(battles, myTag, stat.CardName, stat.CurrentLevel)
 		result = append(result, *stat)
@@ -10,4 +10,13 @@ type CardImpact struct {
 		Battles int
 		WinRate float64
 	}
+	Evolved LevelPerformance // battles where the card was played in its evolved form
+}
+
+// TowerTroopStats - Performance with each support card (tower troop)
+type TowerTroopStats struct {
+	CardName string
+	Battles  int
+	Wins     int
+	WinRate  float64
 }
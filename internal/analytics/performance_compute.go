@@ -13,6 +13,7 @@ func computeOverall(battles []types.Battle, myTag string) OverallStats {
 	}
 
 	runningTrophies := 0
+	hasTrophies := false
 	for _, b := range battles {
 		me := findMyParticipant(b, myTag)
 		if me == nil {
@@ -30,13 +31,24 @@ func computeOverall(battles []types.Battle, myTag string) OverallStats {
 		}
 
 		runningTrophies += int(me.TrophyChange)
-		if runningTrophies > os.PeakTrophies {
-			os.PeakTrophies = runningTrophies
+
+		// Battles outside trophy modes report no trophies
+		if me.StartingTrophies > 0 {
+			after := int(me.StartingTrophies + me.TrophyChange)
+			os.CurrentTrophies = after
+			hasTrophies = true
+			if after > os.PeakTrophies {
+				os.PeakTrophies = after
+			}
 		}
 	}
 
-	os.CurrentTrophies = runningTrophies
 	os.TotalTrophyGain = runningTrophies
+	if !hasTrophies {
+		// Without any trophy counts, the net change is the best estimate
+		os.CurrentTrophies = runningTrophies
+		os.PeakTrophies = max(runningTrophies, 0)
+	}
 
 	if os.TotalBattles > 0 {
 		os.WinRate = float64(os.Wins) / float64(os.TotalBattles) * 100
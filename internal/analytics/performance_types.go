@@ -11,9 +11,9 @@ type OverallStats struct {
 	ThreeCrownRate   float64
 	CurrentStreak    int // positive = wins, negative = losses
 	LongestWinStreak int
-	TotalTrophyGain  int
-	CurrentTrophies  int // latest known
-	PeakTrophies     int
+	TotalTrophyGain  int // net trophy change across the battles
+	CurrentTrophies  int // latest known, from a profile snapshot when one is newer than the last battle
+	PeakTrophies     int // highest count seen in battles or as best trophies in a snapshot
 }
 
 // SessionStats holds statistics for a specific session or time period
@@ -9,20 +9,12 @@ import (
 	"github.com/elliot727/log-gob/internal/types"
 )
 
-// computeProjection estimates time to reach a trophy target.
-func computeProjection(battles []types.Battle, targetTrophies int, myTag string) TrophyProjection {
+// computeProjection estimates time to reach a trophy target from the current trophy count.
+func computeProjection(battles []types.Battle, targetTrophies, currentTrophies int, myTag string) TrophyProjection {
 	if len(battles) == 0 {
 		return TrophyProjection{}
 	}
 
-	currentTrophies := 0
-	for _, b := range battles {
-		me := findMyParticipant(b, myTag)
-		if me != nil {
-			currentTrophies += int(me.TrophyChange)
-		}
-	}
-
 	if targetTrophies <= currentTrophies {
 		return TrophyProjection{TargetTrophies: targetTrophies} // Already there
 	}
@@ -0,0 +1,49 @@
+// Package analytics provides functionality for analyzing Clash Royale battle data.
+package analytics
+
+import (
+	"sort"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// computeTowerTroops analyzes win rates with each support card (tower troop) the player chose.
+func computeTowerTroops(battles []types.Battle, myTag string) []TowerTroopStats {
+	stats := make(map[string]*TowerTroopStats)
+
+	for _, b := range battles {
+		me := findMyParticipant(b, myTag)
+		if me == nil {
+			continue
+		}
+		won := isWin(me.Crowns, b.Opponent[0].Crowns)
+
+		for _, card := range me.SupportCards {
+			stat, exists := stats[card.Name]
+			if !exists {
+				stat = &TowerTroopStats{CardName: card.Name}
+				stats[card.Name] = stat
+			}
+			stat.Battles++
+			if won {
+				stat.Wins++
+			}
+		}
+	}
+
+	result := make([]TowerTroopStats, 0, len(stats))
+	for _, stat := range stats {
+		stat.WinRate = float64(stat.Wins) / float64(stat.Battles) * 100
+		result = append(result, *stat)
+	}
+
+	// Most used first
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Battles != result[j].Battles {
+			return result[i].Battles > result[j].Battles
+		}
+		return result[i].CardName < result[j].CardName
+	})
+
+	return result
+}
@@ -3,15 +3,16 @@ package analytics
 
 // Analytics represents the complete set of analytics computed for a player.
 type Analytics struct {
-	Overall    OverallStats
-	Recent     RecentForm
-	Arenas     []ArenaStats // ordered by progress
-	Projection TrophyProjection
-	Elixir     ElixirStats
-	Crowns     CrownStats
-	Cards      []CardImpact // one per card
-	Losses     LossInsights
-	Challenge  ChallengeProof
+	Overall     OverallStats
+	Recent      RecentForm
+	Arenas      []ArenaStats // ordered by progress
+	Projection  TrophyProjection
+	Elixir      ElixirStats
+	Crowns      CrownStats
+	Cards       []CardImpact      // one per card
+	TowerTroops []TowerTroopStats // most used first
+	Losses      LossInsights
+	Challenge   ChallengeProof
 }
 
 // LevelPerformance holds performance statistics for a specific card level
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source tells where the data returned by GetContext and the endpoint methods came from.
+type Source int
+
+const (
+	FromServer  Source = iota // The server sent the data (200 OK)
+	Revalidated               // The server confirmed the cached data is current (304 Not Modified)
+	FromCache                 // The cached data was still fresh, so no request was made
+)
+
+// Cached reports whether the data is unchanged since it was last fetched.
+func (s Source) Cached() bool {
+	return s != FromServer
+}
+
+func (s Source) String() string {
+	switch s {
+	case FromServer:
+		return "server"
+	case Revalidated:
+		return "revalidated"
+	case FromCache:
+		return "cache"
+	}
+	return "Source(" + strconv.Itoa(int(s)) + ")"
+}
+
+// cacheEntry is a cached response body with its validation metadata.
+type cacheEntry struct {
+	URL     string          `json:"url"`
+	ETag    string          `json:"etag,omitempty"`
+	Expires time.Time       `json:"expires,omitempty"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// fresh reports whether the entry can be used without asking the server.
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return !e.Expires.IsZero() && now.Before(e.Expires)
+}
+
+// diskCache stores one JSON file per request URL. Keying by the full URL rather
+// than the path keeps clients for different API profiles that share a cache
+// directory from seeing each other's data. Writes are best-effort: a cache that
+// can't be written simply behaves like no cache.
+type diskCache struct {
+	dir string
+}
+
+func (c *diskCache) file(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *diskCache) get(url string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.file(url))
+	if err != nil {
+		return nil, false
+	}
+
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != url {
+		return nil, false
+	}
+	return &e, true
+}
+
+func (c *diskCache) put(url string, e *cacheEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+
+	// Write then rename so a concurrent reader never sees a partial entry
+	tmp := c.file(url) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, c.file(url))
+}
+
+// store caches a 200 response unless the server forbids it or gave nothing to revalidate with.
+func (c *diskCache) store(url string, resp *response) {
+	if !json.Valid(resp.body) || hasDirective(resp.header, "no-store") {
+		return
+	}
+
+	e := &cacheEntry{
+		URL:     url,
+		ETag:    resp.header.Get("ETag"),
+		Expires: expiresAt(resp.header, time.Now()),
+		Body:    resp.body,
+	}
+	if e.ETag == "" && e.Expires.IsZero() {
+		return
+	}
+	c.put(url, e)
+}
+
+// expiresAt derives an expiry time from Cache-Control max-age, or zero if the
+// response must always be revalidated.
+func expiresAt(h http.Header, now time.Time) time.Time {
+	if hasDirective(h, "no-cache") {
+		return time.Time{}
+	}
+	for _, d := range cacheDirectives(h) {
+		if v, ok := strings.CutPrefix(d, "max-age="); ok {
+			if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+				return now.Add(time.Duration(secs) * time.Second)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func hasDirective(h http.Header, name string) bool {
+	for _, d := range cacheDirectives(h) {
+		if d == name {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheDirectives(h http.Header) []string {
+	var out []string
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			out = append(out, strings.ToLower(strings.TrimSpace(d)))
+		}
+	}
+	return out
+}
@@ -1,66 +1,314 @@
+// Package api provides an HTTP client for the Clash Royale API.
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/elliot727/log-gob/internal/logging"
 )
 
+// Client talks to the Clash Royale API. Requests made through a single Client
+// share its rate limiter and API keys, so one Client should be reused for a whole run.
 type Client struct {
 	BaseURL string
-	APIKey  string
 	HTTP    *http.Client
+	Retry   RetryPolicy
+
+	auth    AuthScheme
+	headers map[string]string
+	keys    keyRing
+	limiter *rateLimiter
+	cache   *diskCache
+}
+
+// Option configures a Client created by New.
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		c.HTTP = h
+	}
+}
+
+// WithTransport sets the transport used by the HTTP client, e.g. a
+// RecordingTransport or ReplayTransport. The client is copied first, so an
+// *http.Client passed to WithHTTPClient is left untouched.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		hc := *c.HTTP
+		hc.Transport = rt
+		c.HTTP = &hc
+	}
 }
 
-func New(baseURL, apiKey string) *Client {
-	return &Client{
+// WithCache keeps successful responses in dir and revalidates them with
+// If-None-Match, honoring the server's Cache-Control max-age. GetContext and
+// endpoint methods such as BattleLog return the cached result for unchanged
+// responses, with a Source telling whether the server was asked. An empty dir
+// disables caching.
+func WithCache(dir string) Option {
+	return func(c *Client) {
+		if dir == "" {
+			c.cache = nil
+			return
+		}
+		c.cache = &diskCache{dir: dir}
+	}
+}
+
+// WithKeys replaces the client's API keys. Requests use the first key until
+// it is rejected for the current IP (accessDenied.invalidIp) or throttled,
+// then rotate through the rest.
+func WithKeys(keys ...Key) Option {
+	return func(c *Client) {
+		c.keys = keyRing{keys: keys}
+	}
+}
+
+// WithPreferredIP tries keys bound to ip before any others. Use it to pick
+// the right key profile on a machine whose IP is known in advance.
+func WithPreferredIP(ip string) Option {
+	return func(c *Client) {
+		if ip != "" {
+			c.keys.prefer(ip)
+		}
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.Retry = p
+	}
+}
+
+// WithRateLimit limits the client to perSecond requests per second with bursts
+// of up to burst requests. A non-positive perSecond disables client-side limiting.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(perSecond, burst)
+	}
+}
+
+// New creates a client for the API at baseURL authenticating with apiKey.
+// Use WithKeys to configure several keys instead. By default requests are
+// retried according to DefaultRetryPolicy and limited to DefaultRateLimit
+// requests per second.
+func New(baseURL, apiKey string, opts ...Option) *Client {
+	c := &Client{
 		BaseURL: baseURL,
-		APIKey:  apiKey,
 		HTTP: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		Retry:   DefaultRetryPolicy,
+		auth:    AuthBearer,
+		limiter: newRateLimiter(DefaultRateLimit, DefaultRateBurst),
+	}
+	if apiKey != "" {
+		c.keys.keys = []Key{{Token: apiKey}}
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ActiveKey returns the key used for the most recent successful request, or
+// the key the next request will try first.
+func (c *Client) ActiveKey() Key {
+	return c.keys.current()
 }
 
-func (c *Client) request(path string) (*http.Request, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+path, nil)
+func (c *Client) request(ctx context.Context, path, etag string, key Key) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+path, nil)
 	if err != nil {
 		return nil, err
 	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if c.auth == AuthBearer && key.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+key.Token)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
 	return req, nil
 }
 
-func (c *Client) Get(path string, out interface{}) error {
-	req, err := c.request(path)
+// Get is GetContext with a background context.
+func (c *Client) Get(path string, out interface{}) (Source, error) {
+	return c.GetContext(context.Background(), path, out)
+}
+
+// GetContext fetches path and decodes the JSON response into out. Rate-limited,
+// server-error and network failures are retried according to c.Retry.
+// Non-200 responses are returned as *Error. Cancelling ctx aborts the request
+// and any pending backoff, returning ctx.Err().
+//
+// With a cache configured (see WithCache), out is populated from the cache when
+// the cached response is still fresh (FromCache) or the server answers 304 Not
+// Modified (Revalidated). Otherwise the Source is FromServer.
+func (c *Client) GetContext(ctx context.Context, path string, out interface{}) (Source, error) {
+	url := c.BaseURL + path
+
+	var cached *cacheEntry
+	if c.cache != nil {
+		if entry, ok := c.cache.get(url); ok {
+			if entry.fresh(time.Now()) {
+				logging.FromContext(ctx).Debug("API response served from cache", "path", path)
+				return FromCache, json.Unmarshal(entry.Body, out)
+			}
+			cached = entry
+		}
+	}
+
+	etag := ""
+	if cached != nil {
+		etag = cached.ETag
+	}
+
+	resp, err := c.fetch(ctx, path, etag)
 	if err != nil {
-		return err
+		return FromServer, err
+	}
+
+	if resp.status == http.StatusNotModified && cached != nil {
+		cached.Expires = expiresAt(resp.header, time.Now())
+		c.cache.put(url, cached)
+		return Revalidated, json.Unmarshal(cached.Body, out)
+	}
+
+	if len(resp.body) == 0 {
+		return FromServer, errors.New("empty response body")
+	}
+	if err := json.Unmarshal(resp.body, out); err != nil {
+		return FromServer, err
+	}
+
+	if c.cache != nil {
+		c.cache.store(url, resp)
+	}
+	return FromServer, nil
+}
+
+// fetch performs a request with retries, returning the first 200 or 304 response.
+// Key-specific failures switch to the next key immediately, trying each key at
+// most once before falling back to the retry policy.
+func (c *Client) fetch(ctx context.Context, path, etag string) (*response, error) {
+	logger := logging.FromContext(ctx).With("path", path)
+
+	rotations := 0
+	for attempt := 0; ; {
+		key := c.keys.current()
+		start := time.Now()
+		resp, retryAfter, err := c.do(ctx, path, etag, key)
+		if err == nil {
+			logger.Debug("API request", "status", resp.status, "key", key.Label(), "duration", time.Since(start))
+			return resp, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		if shouldRotate(err) && rotations < c.keys.len()-1 {
+			c.keys.rotate(key)
+			rotations++
+			logger.Warn("API key rejected, switching keys", "key", key.Label(), "next_key", c.keys.current().Label(), "err", err)
+			continue
+		}
+
+		if !isRetryable(err) || attempt >= c.Retry.MaxRetries {
+			logger.Debug("API request failed", "key", key.Label(), "attempts", attempt+1, "err", err)
+			return nil, err
+		}
+
+		delay := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+			if c.Retry.MaxDelay > 0 {
+				delay = min(retryAfter, c.Retry.MaxDelay)
+			}
+		}
+		logger.Warn("API request failed, retrying", "key", key.Label(), "attempt", attempt+1, "delay", delay, "err", err)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
+}
+
+// response is a successful (200 or 304) response read into memory.
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do performs a single request attempt. On failure it returns the server's
+// Retry-After hint (if any) alongside the error.
+func (c *Client) do(ctx context.Context, path, etag string, key Key) (*response, time.Duration, error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	req, err := c.request(ctx, path, etag, key)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
-		return err
+		return nil, 0, &networkError{err: err}
 	}
 	defer resp.Body.Close()
 
 	body, readErr := io.ReadAll(resp.Body)
 	if readErr != nil {
-		return readErr
+		return nil, 0, &networkError{err: readErr}
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, retryAfter, newError(resp.StatusCode, body)
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	return &response{status: resp.StatusCode, header: resp.Header, body: body}, 0, nil
+}
+
+// networkError wraps transport-level failures so they can be retried.
+type networkError struct {
+	err error
+}
 
-		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+func (e *networkError) Error() string { return e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
 
+// sleep pauses for d or until ctx is cancelled, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
 	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	if len(body) == 0 {
-		return errors.New("empty response body")
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-
-	return json.Unmarshal(body, out)
 }
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/fakeapi"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+func TestBattleLogRevalidatesCache(t *testing.T) {
+	fake := fakeapi.New()
+	fake.SetBattleLog("#2PP", []types.Battle{
+		{BattleTime: "20251012T190501.000Z", BattleType: "PvP", Team: []types.Player{{Tag: "#2PP"}}, Opponent: []types.Player{{Tag: "#8QQ"}}},
+	})
+	srv := fake.Start()
+	defer srv.Close()
+
+	client := api.New(srv.URL, "token", api.WithCache(t.TempDir()))
+	ctx := context.Background()
+
+	battles, src, err := client.BattleLog(ctx, "#2PP")
+	if err != nil || src != api.FromServer {
+		t.Fatalf("first fetch: source %v, err %v", src, err)
+	}
+	if len(battles) != 1 || battles[0].Team[0].Tag != "#2PP" {
+		t.Fatalf("first fetch returned %+v", battles)
+	}
+
+	battles, src, err = client.BattleLog(ctx, "#2PP")
+	if err != nil || src != api.Revalidated {
+		t.Fatalf("second fetch: source %v, err %v; want revalidated", src, err)
+	}
+	if len(battles) != 1 {
+		t.Errorf("second fetch returned %d cached battles, want 1", len(battles))
+	}
+	if n := fake.Requests(); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+}
+
+func TestReplayFixture(t *testing.T) {
+	client := api.New("https://api.clashroyale.com", "", api.WithTransport(&api.ReplayTransport{Dir: "../../fixtures"}))
+	ctx := context.Background()
+
+	battles, _, err := client.BattleLog(ctx, "#2PP")
+	if err != nil {
+		t.Fatalf("BattleLog: %v", err)
+	}
+	if len(battles) == 0 {
+		t.Fatal("replayed battle log is empty")
+	}
+	for _, b := range battles {
+		if b.BattleTime == "" || len(b.Team) == 0 || b.Team[0].Tag != "#2PP" {
+			t.Errorf("unexpected replayed battle %+v", b)
+		}
+	}
+
+	if _, _, err := client.Player(ctx, "#9ZZ"); err == nil {
+		t.Error("Player with no recorded fixture succeeded")
+	}
+}
+
+func TestFreshCacheSkipsRequest(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, `{"items":[{"id":26000000,"name":"Knight"}]}`)
+	}))
+	defer srv.Close()
+
+	client := api.New(srv.URL, "token", api.WithCache(t.TempDir()))
+	ctx := context.Background()
+
+	for i, want := range []api.Source{api.FromServer, api.FromCache} {
+		cards, src, err := client.Cards(ctx)
+		if err != nil || src != want {
+			t.Fatalf("fetch %d: source %v, err %v; want %v", i, src, err, want)
+		}
+		if len(cards) != 1 || cards[0].Name != "Knight" {
+			t.Errorf("fetch %d returned %+v", i, cards)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+}
+
+// TestCacheKeyedByBaseURL checks that clients for different endpoints sharing a
+// cache directory never get each other's responses.
+func TestCacheKeyedByBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	for _, name := range []string{"Knight", "Archers"} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			fmt.Fprintf(w, `{"items":[{"id":26000000,"name":%q}]}`, name)
+		}))
+		client := api.New(srv.URL, "token", api.WithCache(dir))
+
+		cards, src, err := client.Cards(ctx)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Cards: %v", err)
+		}
+		if src != api.FromServer || len(cards) != 1 || cards[0].Name != name {
+			t.Errorf("Cards from %s server = %+v from %v", name, cards, src)
+		}
+	}
+}
+
+// TestRetryAfterCappedByMaxDelay checks that a huge Retry-After cannot stall
+// the client beyond the retry policy's MaxDelay.
+func TestRetryAfterCappedByMaxDelay(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"items":[]}`)
+	}))
+	defer srv.Close()
+
+	policy := api.RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	client := api.New(srv.URL, "token", api.WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, _, err := client.Cards(ctx); err != nil {
+		t.Fatalf("Cards: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("server saw %d requests, want 2", requests)
+	}
+}
+
+func TestWithTransportCopiesClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	client := api.New("http://example.invalid", "token",
+		api.WithHTTPClient(shared),
+		api.WithTransport(&api.ReplayTransport{Dir: t.TempDir()}))
+
+	if shared.Transport != nil {
+		t.Error("WithTransport replaced the transport of the caller's client")
+	}
+	if client.HTTP == shared || client.HTTP.Timeout != time.Minute {
+		t.Errorf("client HTTP = %+v, want a copy of the caller's client", client.HTTP)
+	}
+}
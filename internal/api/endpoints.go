@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// itemsResponse is the envelope used by list endpoints such as upcomingchests and members.
+type itemsResponse[T any] struct {
+	Items []T `json:"items"`
+}
+
+// playerPath normalizes tag and builds /v1/players/{tag}{suffix}.
+func playerPath(tag, suffix string) (string, error) {
+	normalized, err := types.NormalizeTag(tag)
+	if err != nil {
+		return "", err
+	}
+	return "/v1/players/" + url.PathEscape(normalized) + suffix, nil
+}
+
+// clanPath normalizes tag and builds /v1/clans/{tag}{suffix}.
+func clanPath(tag, suffix string) (string, error) {
+	normalized, err := types.NormalizeTag(tag)
+	if err != nil {
+		return "", err
+	}
+	return "/v1/clans/" + url.PathEscape(normalized) + suffix, nil
+}
+
+// BattleLogSize is the number of battles the API keeps in a player's battle log.
+const BattleLogSize = 25
+
+// BattleLog returns the player's most recent battles (the API keeps the last BattleLogSize), newest first.
+func (c *Client) BattleLog(ctx context.Context, tag string) ([]types.Battle, Source, error) {
+	path, err := playerPath(tag, "/battlelog")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var battles []types.Battle
+	src, err := c.GetContext(ctx, path, &battles)
+	if err != nil {
+		return nil, src, err
+	}
+	return battles, src, nil
+}
+
+// Player returns the player's profile.
+func (c *Client) Player(ctx context.Context, tag string) (*types.PlayerProfile, Source, error) {
+	path, err := playerPath(tag, "")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var profile types.PlayerProfile
+	src, err := c.GetContext(ctx, path, &profile)
+	if err != nil {
+		return nil, src, err
+	}
+	return &profile, src, nil
+}
+
+// UpcomingChests returns the player's upcoming chest cycle.
+func (c *Client) UpcomingChests(ctx context.Context, tag string) ([]types.Chest, Source, error) {
+	path, err := playerPath(tag, "/upcomingchests")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var resp itemsResponse[types.Chest]
+	src, err := c.GetContext(ctx, path, &resp)
+	if err != nil {
+		return nil, src, err
+	}
+	return resp.Items, src, nil
+}
+
+// Clan returns the clan's details, including its member list.
+func (c *Client) Clan(ctx context.Context, tag string) (*types.Clan, Source, error) {
+	path, err := clanPath(tag, "")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var clan types.Clan
+	src, err := c.GetContext(ctx, path, &clan)
+	if err != nil {
+		return nil, src, err
+	}
+	return &clan, src, nil
+}
+
+// ClanMembers returns the clan's current members.
+func (c *Client) ClanMembers(ctx context.Context, tag string) ([]types.ClanMember, Source, error) {
+	path, err := clanPath(tag, "/members")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var resp itemsResponse[types.ClanMember]
+	src, err := c.GetContext(ctx, path, &resp)
+	if err != nil {
+		return nil, src, err
+	}
+	return resp.Items, src, nil
+}
+
+// CurrentRiverRace returns the clan's river race in progress.
+func (c *Client) CurrentRiverRace(ctx context.Context, tag string) (*types.RiverRace, Source, error) {
+	path, err := clanPath(tag, "/currentriverrace")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var race types.RiverRace
+	src, err := c.GetContext(ctx, path, &race)
+	if err != nil {
+		return nil, src, err
+	}
+	return &race, src, nil
+}
+
+// RiverRaceLog returns the clan's finished river races, most recent first.
+func (c *Client) RiverRaceLog(ctx context.Context, tag string) ([]types.RiverRaceLogEntry, Source, error) {
+	path, err := clanPath(tag, "/riverracelog")
+	if err != nil {
+		return nil, FromServer, err
+	}
+
+	var resp itemsResponse[types.RiverRaceLogEntry]
+	src, err := c.GetContext(ctx, path, &resp)
+	if err != nil {
+		return nil, src, err
+	}
+	return resp.Items, src, nil
+}
+
+// cardsResponse is the envelope returned by /v1/cards.
+type cardsResponse struct {
+	Items        []types.Card `json:"items"`
+	SupportItems []types.Card `json:"supportItems"`
+}
+
+// Cards returns every card in the game, including support cards (tower troops).
+func (c *Client) Cards(ctx context.Context) ([]types.Card, Source, error) {
+	var resp cardsResponse
+	src, err := c.GetContext(ctx, "/v1/cards", &resp)
+	if err != nil {
+		return nil, src, err
+	}
+	return append(resp.Items, resp.SupportItems...), src, nil
+}
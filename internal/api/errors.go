@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *Error through errors.Is.
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrAccessDenied = errors.New("access denied")
+	ErrNotFound     = errors.New("not found")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrMaintenance  = errors.New("api in maintenance")
+	ErrServer       = errors.New("server error")
+)
+
+// Error is returned for any non-200 response from the Clash Royale API.
+// Reason and Message come from the API's JSON error body when present.
+type Error struct {
+	StatusCode int    // HTTP status code
+	Reason     string // e.g. "accessDenied.invalidIp", "notFound", "inMaintenance"
+	Message    string // Human-readable message from the API
+	Type       string // Optional error type from the API
+	Body       string // Raw response body, kept when it could not be parsed
+}
+
+// apiErrorBody mirrors the JSON error payload returned by the API.
+type apiErrorBody struct {
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// newError builds an *Error from a response status and body.
+func newError(status int, body []byte) *Error {
+	e := &Error{StatusCode: status}
+
+	var parsed apiErrorBody
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Reason != "" {
+		e.Reason = parsed.Reason
+		e.Message = parsed.Message
+		e.Type = parsed.Type
+	} else {
+		e.Body = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Reason != "" && e.Message != "":
+		return fmt.Sprintf("clash royale api: status %d (%s): %s", e.StatusCode, e.Reason, e.Message)
+	case e.Reason != "":
+		return fmt.Sprintf("clash royale api: status %d (%s)", e.StatusCode, e.Reason)
+	case e.Body != "":
+		return fmt.Sprintf("clash royale api: status %d: %s", e.StatusCode, e.Body)
+	default:
+		return fmt.Sprintf("clash royale api: status %d", e.StatusCode)
+	}
+}
+
+// Is matches e against the package's sentinel errors, preferring the API's
+// reason field and falling back to the HTTP status code. Only the
+// "inMaintenance" reason means ErrMaintenance; any other 503 is ErrServer.
+func (e *Error) Is(target error) bool {
+	return e.kind() == target
+}
+
+// kind maps the error to its sentinel value, or nil if none applies.
+func (e *Error) kind() error {
+	switch {
+	case e.Reason == "badRequest":
+		return ErrBadRequest
+	case strings.HasPrefix(e.Reason, "accessDenied"):
+		return ErrAccessDenied
+	case e.Reason == "notFound":
+		return ErrNotFound
+	case e.Reason == "requestThrottled":
+		return ErrRateLimited
+	case e.Reason == "inMaintenance":
+		return ErrMaintenance
+	}
+
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusForbidden:
+		return ErrAccessDenied
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// InvalidIP reports whether the API key was rejected because the request
+// came from an IP address the key is not bound to.
+func (e *Error) InvalidIP() bool {
+	return e.Reason == "accessDenied.invalidIp"
+}
+
+// Temporary reports whether repeating the request shortly may succeed.
+// Maintenance is not considered temporary as it usually lasts longer than
+// a retry window; schedulers should back off on ErrMaintenance themselves.
+func (e *Error) Temporary() bool {
+	switch e.kind() {
+	case ErrRateLimited, ErrServer:
+		return true
+	}
+	return false
+}
+
+// Hint returns an actionable message for an error returned by the client,
+// suitable for showing to the user. It returns an empty string when there
+// is nothing more useful to say than the error itself.
+func Hint(err error) string {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	switch {
+	case apiErr.InvalidIP():
+		return "No configured API key is allowed from this IP address. Create a key for your current IP at https://developer.clashroyale.com and add it to APIKEYS."
+	case errors.Is(err, ErrAccessDenied):
+		return "The API key was rejected. Check APIKEY in your .env file."
+	case errors.Is(err, ErrNotFound):
+		return "No player or clan exists with that tag. Check the tag that was looked up for typos."
+	case errors.Is(err, ErrRateLimited):
+		return "The API is throttling requests. Wait a moment and try again."
+	case errors.Is(err, ErrMaintenance):
+		return "The Clash Royale API is in maintenance. Try again later."
+	case errors.Is(err, ErrBadRequest):
+		return "The request was malformed. Check that the tag contains only valid characters."
+	}
+	return ""
+}
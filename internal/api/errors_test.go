@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      error
+		temporary bool
+	}{
+		{"maintenance", http.StatusServiceUnavailable, `{"reason":"inMaintenance"}`, ErrMaintenance, false},
+		{"bare 503", http.StatusServiceUnavailable, "", ErrServer, true},
+		{"503 with HTML body", http.StatusServiceUnavailable, "<html>Service Unavailable</html>", ErrServer, true},
+		{"server error", http.StatusInternalServerError, `{"reason":"unknownException"}`, ErrServer, true},
+		{"throttled", http.StatusTooManyRequests, `{"reason":"requestThrottled"}`, ErrRateLimited, true},
+		{"invalid IP", http.StatusForbidden, `{"reason":"accessDenied.invalidIp"}`, ErrAccessDenied, false},
+		{"not found", http.StatusNotFound, "", ErrNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newError(tt.status, []byte(tt.body))
+			if !errors.Is(e, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", e, tt.want)
+			}
+			if got := e.Temporary(); got != tt.temporary {
+				t.Errorf("Temporary() = %v, want %v", got, tt.temporary)
+			}
+		})
+	}
+}
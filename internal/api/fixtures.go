@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Fixture is an HTTP exchange recorded to disk by RecordingTransport and
+// served back by ReplayTransport.
+type Fixture struct {
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	RequestHeader http.Header     `json:"requestHeader,omitempty"` // Authorization is always scrubbed
+	Status        int             `json:"status"`
+	Header        http.Header     `json:"header,omitempty"`
+	Body          json.RawMessage `json:"body,omitempty"`     // Set when the response body is valid JSON
+	BodyText      string          `json:"bodyText,omitempty"` // Set otherwise
+}
+
+// scrubbedHeaders are never written to fixture files.
+var scrubbedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// FixtureName returns the file name used for a request's fixture, e.g.
+// "GET_v1_players_ABC123_battlelog.json" for GET /v1/players/%23ABC123/battlelog.
+func FixtureName(method string, u *url.URL) string {
+	p, err := url.PathUnescape(u.EscapedPath())
+	if err != nil {
+		p = u.EscapedPath()
+	}
+	if u.RawQuery != "" {
+		p += "_" + u.RawQuery
+	}
+
+	p = strings.ReplaceAll(p, "#", "")
+	p = strings.Trim(p, "/")
+	p = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, p)
+
+	return method + "_" + p + ".json"
+}
+
+// LoadFixture reads the fixture for method and u from dir.
+func LoadFixture(dir, method string, u *url.URL) (*Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FixtureName(method, u)))
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", FixtureName(method, u), err)
+	}
+	return &f, nil
+}
+
+// RecordingTransport passes requests through to Base and saves every
+// response to Dir as a Fixture, overwriting earlier recordings. 304 Not
+// Modified responses are not recorded so they never replace a full body.
+type RecordingTransport struct {
+	Base http.RoundTripper // Defaults to http.DefaultTransport
+	Dir  string
+}
+
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	f := Fixture{
+		Method:        req.Method,
+		Path:          req.URL.RequestURI(),
+		RequestHeader: scrub(req.Header),
+		Status:        resp.StatusCode,
+		Header:        scrub(resp.Header),
+	}
+	if json.Valid(body) {
+		f.Body = body
+	} else {
+		f.BodyText = string(body)
+	}
+
+	if err := writeFixture(t.Dir, FixtureName(req.Method, req.URL), &f); err != nil {
+		return nil, fmt.Errorf("recording fixture: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ReplayTransport serves responses from fixtures in Dir without touching the
+// network. Requests with no recorded fixture fail with an error.
+type ReplayTransport struct {
+	Dir string
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f, err := LoadFixture(t.Dir, req.Method, req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	return f.Response(req), nil
+}
+
+// Response builds the recorded response for req.
+func (f *Fixture) Response(req *http.Request) *http.Response {
+	body := []byte(f.Body)
+	if len(body) == 0 {
+		body = []byte(f.BodyText)
+	}
+
+	header := f.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// scrub copies h without credentials.
+func scrub(h http.Header) http.Header {
+	out := h.Clone()
+	for _, k := range scrubbedHeaders {
+		out.Del(k)
+	}
+	return out
+}
+
+func writeFixture(dir, name string, f *Fixture) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, name), append(data, '\n'), 0o644)
+}
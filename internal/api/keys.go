@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"sync"
+)
+
+// Key is an API key, optionally labeled with the IP address it is bound to.
+type Key struct {
+	Token string
+	IP    string
+}
+
+// Label identifies the key in logs without revealing it: the bound IP when
+// known, otherwise the last few characters of the token.
+func (k Key) Label() string {
+	if k.IP != "" {
+		return k.IP
+	}
+	if len(k.Token) <= 6 {
+		return "…"
+	}
+	return "…" + k.Token[len(k.Token)-6:]
+}
+
+// keyRing holds the client's keys and which one is in use. Requests use the
+// active key until it is rejected for the caller's IP or throttled, at which
+// point the ring rotates to the next key.
+type keyRing struct {
+	mu     sync.Mutex
+	keys   []Key
+	active int
+}
+
+// current returns the key requests should use, or the zero Key if there are none.
+func (r *keyRing) current() Key {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.keys) == 0 {
+		return Key{}
+	}
+	return r.keys[r.active]
+}
+
+// rotate moves past failed if it is still the active key. Checking first keeps
+// concurrent callers that saw the same failure from skipping a good key.
+func (r *keyRing) rotate(failed Key) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.keys) > 1 && r.keys[r.active] == failed {
+		r.active = (r.active + 1) % len(r.keys)
+	}
+}
+
+func (r *keyRing) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.keys)
+}
+
+// prefer moves keys bound to ip to the front, keeping the relative order otherwise.
+func (r *keyRing) prefer(ip string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matching, rest []Key
+	for _, k := range r.keys {
+		if k.IP == ip {
+			matching = append(matching, k)
+		} else {
+			rest = append(rest, k)
+		}
+	}
+	r.keys = append(matching, rest...)
+	r.active = 0
+}
+
+// shouldRotate reports whether err is specific to the key used, so another
+// key may succeed: the key is bound to a different IP, or its quota is spent.
+func shouldRotate(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.InvalidIP() || errors.Is(apiErr, ErrRateLimited)
+}
@@ -0,0 +1,76 @@
+package api
+
+import "sort"
+
+// AuthScheme selects how a client authenticates against an endpoint.
+type AuthScheme string
+
+const (
+	AuthBearer AuthScheme = "bearer" // "Authorization: Bearer <key>"
+	AuthNone   AuthScheme = "none"   // No credentials, e.g. a local fake server
+)
+
+// Profile describes an endpoint the client can talk to.
+type Profile struct {
+	Name    string
+	BaseURL string
+	Auth    AuthScheme
+	Headers map[string]string // Extra headers sent with every request
+}
+
+// Built-in endpoint profiles.
+var (
+	// ProfileOfficial is the official Clash Royale API. Keys must be bound to the caller's IP.
+	ProfileOfficial = Profile{
+		Name:    "official",
+		BaseURL: "https://api.clashroyale.com",
+		Auth:    AuthBearer,
+	}
+
+	// ProfileProxy is the RoyaleAPI community proxy, which forwards requests
+	// from a fixed IP. Keys must be bound to 45.79.218.79 instead of the
+	// caller's IP, so it works from machines with dynamic addresses.
+	ProfileProxy = Profile{
+		Name:    "proxy",
+		BaseURL: "https://proxy.royaleapi.dev",
+		Auth:    AuthBearer,
+	}
+
+	// ProfileLocal is the fake server from cmd/fakeapi on its default address.
+	ProfileLocal = Profile{
+		Name:    "local",
+		BaseURL: "http://127.0.0.1:8080",
+		Auth:    AuthNone,
+	}
+)
+
+var profiles = map[string]Profile{
+	ProfileOfficial.Name: ProfileOfficial,
+	ProfileProxy.Name:    ProfileProxy,
+	ProfileLocal.Name:    ProfileLocal,
+}
+
+// LookupProfile returns the built-in profile with the given name.
+func LookupProfile(name string) (Profile, bool) {
+	p, ok := profiles[name]
+	return p, ok
+}
+
+// ProfileNames lists the built-in profile names in alphabetical order.
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfile points the client at p's base URL and uses its auth scheme and headers.
+func WithProfile(p Profile) Option {
+	return func(c *Client) {
+		c.BaseURL = p.BaseURL
+		c.auth = p.Auth
+		c.headers = p.Headers
+	}
+}
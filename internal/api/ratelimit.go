@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Default client-side rate limit applied by New.
+const (
+	DefaultRateLimit = 10.0 // requests per second
+	DefaultRateBurst = 10
+)
+
+// rateLimiter is a token bucket shared by every request made through a Client.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64 // bucket capacity
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// wait blocks until a request may be sent or ctx is cancelled.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	return sleep(ctx, l.reserve())
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+type RetryPolicy struct {
+	MaxRetries int           // Retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // Backoff ceiling for the first retry, doubled each attempt
+	MaxDelay   time.Duration // Upper bound for the backoff ceiling and for Retry-After
+}
+
+// DefaultRetryPolicy retries up to four times, backing off from half a second up to 30 seconds.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 4,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// backoff returns a randomized delay before retry number attempt (starting at 0),
+// using "full jitter": a uniform value between zero and the exponential ceiling.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 0; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// isRetryable reports whether a failed attempt is worth repeating:
+// network errors, rate limiting and server errors other than maintenance.
+func isRetryable(err error) bool {
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+
+	return false
+}
+
+// parseRetryAfter interprets a Retry-After header given either as a number of
+// seconds or as an HTTP date. It returns zero when the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
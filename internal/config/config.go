@@ -2,17 +2,55 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/types"
 	"github.com/joho/godotenv"
 )
 
 // Config holds all the application configuration values
 type Config struct {
-	DBPath      string
-	APIKey      string
-	PlayerTag   string
-	APIBaseURL  string
+	DBPath     string
+	APIKey     string            // The first configured key, kept for single-key setups
+	APIKeys    []APIKey          // Every configured key, tried in order
+	APIIP      string            // This machine's IP; keys bound to it are tried first
+	PlayerTag  string            // The default "me"; always tracked
+	APIBaseURL string            // Overrides the profile's base URL when set
+	APIProfile string            // Endpoint profile name, e.g. "official", "proxy" or "local"
+	APIHeaders map[string]string // Extra headers sent with every API request
+	ClanTag    string
+
+	// Extra players whose battle logs are fetched, kept in a JSON file managed by "loggob players"
+	PlayersFile string
+	Players     []TrackedPlayer
+
+	// Offline development: record API responses to, or replay them from, a fixture directory
+	APIRecordDir string
+	APIReplayDir string
+
+	// Directory for cached API responses; empty disables caching
+	APICacheDir string
+
+	// Ingestion filter: battle types and game mode IDs to store; empty stores everything
+	IngestBattleTypes []string
+	IngestGameModes   []int32
+
+	// Logging: minimum level, handler format and an optional file to log to instead of stderr
+	LogLevel  slog.Level
+	LogFormat logging.Format
+	LogFile   string
+}
+
+// APIKey is a Clash Royale API key, optionally labeled with the IP address it is bound to.
+type APIKey struct {
+	Token string
+	IP    string
 }
 
 // Load loads configuration from environment variables with sensible defaults
@@ -20,16 +58,80 @@ func Load() (*Config, error) {
 	// Load environment variables from .env file if it exists
 	_ = godotenv.Load() // Ignore errors if .env file doesn't exist
 
+	playerTag, err := getTag("PLAYERTAG")
+	if err != nil {
+		return nil, err
+	}
+
+	clanTag, err := getTag("CLAN_TAG")
+	if err != nil {
+		return nil, err
+	}
+
+	gameModes, err := getGameModes("INGEST_GAME_MODES")
+	if err != nil {
+		return nil, err
+	}
+
+	logLevel, err := logging.ParseLevel(getEnv("LOG_LEVEL"))
+	if err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+
+	logFormat, err := logging.ParseFormat(getEnv("LOG_FORMAT"))
+	if err != nil {
+		return nil, fmt.Errorf("LOG_FORMAT: %w", err)
+	}
+
+	playersFile := getEnvOrDefault("PLAYERS_FILE", "players.json")
+	players, err := LoadPlayers(playersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys := getAPIKeys()
+	apiKey := ""
+	if len(apiKeys) > 0 {
+		apiKey = apiKeys[0].Token
+	}
+
 	cfg := &Config{
 		// Database configuration
 		DBPath: getEnvOrDefault("DB_PATH", "battles.db"),
-		
+
 		// API configuration
-		APIBaseURL: getEnvOrDefault("API_BASE_URL", "https://api.clashroyale.com"),
-		
+		APIBaseURL: getEnv("API_BASE_URL"),
+		APIProfile: getEnvOrDefault("API_PROFILE", "official"),
+		APIHeaders: getHeaders("API_HEADERS"),
+
 		// Required environment variables
-		APIKey: getEnv("APIKEY"),
-		PlayerTag: getPlayerTag(),
+		APIKey:    apiKey,
+		APIKeys:   apiKeys,
+		APIIP:     getEnv("API_IP"),
+		PlayerTag: playerTag,
+
+		// Optional clan to sync with "clan sync"
+		ClanTag: clanTag,
+
+		// Other tracked players
+		PlayersFile: playersFile,
+		Players:     players,
+
+		// Fixture recording and replay
+		APIRecordDir: getEnv("API_RECORD_DIR"),
+		APIReplayDir: getEnv("API_REPLAY_DIR"),
+
+		// Response cache for conditional requests
+		APICacheDir: getCacheDir(),
+
+		// Which battles to store
+		IngestBattleTypes: getList("INGEST_BATTLE_TYPES"),
+		IngestGameModes:   gameModes,
+
+		// Structured logging
+		LogLevel:  logLevel,
+		LogFormat: logFormat,
+		LogFile:   getEnv("LOG_FILE"),
 	}
 
 	return cfg, nil
@@ -48,17 +150,96 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
-// getPlayerTag retrieves the player tag with validation
-func getPlayerTag() string {
-	playerTag := os.Getenv("PLAYERTAG")
-	if playerTag == "" {
+// getTag retrieves a player or clan tag, normalized to the "#TAG" form used by the API.
+// An unset variable yields an empty tag; a malformed one yields an error.
+func getTag(key string) (string, error) {
+	tag := os.Getenv(key)
+	if tag == "" {
+		return "", nil
+	}
+
+	normalized, err := types.NormalizeTag(tag)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", key, err)
+	}
+	return normalized, nil
+}
+
+// getCacheDir returns API_CACHE_DIR, defaulting to a "loggob" directory in the
+// user's cache directory. Setting API_CACHE_DIR=off disables the cache.
+func getCacheDir() string {
+	dir := os.Getenv("API_CACHE_DIR")
+	switch dir {
+	case "off":
 		return ""
+	case "":
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(base, "loggob")
+	}
+	return dir
+}
+
+// getAPIKeys collects APIKEY and the comma-separated APIKEYS list. Each APIKEYS
+// entry is a token, optionally followed by "@" and the IP it is bound to.
+func getAPIKeys() []APIKey {
+	var keys []APIKey
+	seen := make(map[string]bool)
+
+	add := func(k APIKey) {
+		if k.Token == "" || seen[k.Token] {
+			return
+		}
+		seen[k.Token] = true
+		keys = append(keys, k)
+	}
+
+	add(APIKey{Token: strings.TrimSpace(getEnv("APIKEY"))})
+
+	for _, entry := range strings.Split(getEnv("APIKEYS"), ",") {
+		token, ip, _ := strings.Cut(strings.TrimSpace(entry), "@")
+		add(APIKey{Token: strings.TrimSpace(token), IP: strings.TrimSpace(ip)})
+	}
+
+	return keys
+}
+
+// getHeaders parses a comma-separated list of Name=value pairs.
+func getHeaders(key string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(key), ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		headers[name] = strings.TrimSpace(value)
 	}
+	return headers
+}
 
-	// Ensure player tag has proper format for API request
-	if playerTag[0] != '#' {
-		playerTag = "#" + playerTag
+// getList parses a comma-separated list, dropping empty entries.
+func getList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(getEnv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
 	}
+	return values
+}
 
-	return playerTag
-}
\ No newline at end of file
+// getGameModes parses a comma-separated list of numeric game mode IDs.
+func getGameModes(key string) ([]int32, error) {
+	var ids []int32
+	for _, v := range getList(key) {
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid game mode ID %q", key, v)
+		}
+		ids = append(ids, int32(id))
+	}
+	return ids, nil
+}
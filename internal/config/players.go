@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// TrackedPlayer is a player whose battle log is fetched alongside PLAYERTAG.
+type TrackedPlayer struct {
+	Tag  string `json:"tag"`
+	Name string `json:"name,omitempty"`
+}
+
+// TrackedTags returns every player to ingest: PLAYERTAG first, then the players file, without duplicates.
+func (c *Config) TrackedTags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	add := func(tag string) {
+		if tag == "" || seen[tag] {
+			return
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	add(c.PlayerTag)
+	for _, p := range c.Players {
+		add(p.Tag)
+	}
+
+	return tags
+}
+
+// LoadPlayers reads the tracked players file. A missing file means no players are tracked.
+func LoadPlayers(path string) ([]TrackedPlayer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var players []TrackedPlayer
+	if err := json.Unmarshal(data, &players); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, p := range players {
+		tag, err := types.NormalizeTag(p.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		players[i].Tag = tag
+	}
+
+	return players, nil
+}
+
+// SavePlayers writes the tracked players file, replacing it atomically so a
+// crash never leaves it truncated.
+func SavePlayers(path string, players []TrackedPlayer) error {
+	if players == nil {
+		players = []TrackedPlayer{}
+	}
+	data, err := json.MarshalIndent(players, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// FindPlayer returns the index of tag in players, or -1. Tags are compared case-insensitively.
+func FindPlayer(players []TrackedPlayer, tag string) int {
+	for i, p := range players {
+		if strings.EqualFold(p.Tag, tag) {
+			return i
+		}
+	}
+	return -1
+}
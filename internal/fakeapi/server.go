@@ -0,0 +1,250 @@
+// Package fakeapi provides an in-process fake of the Clash Royale API that serves
+// players and battle logs from memory or from fixtures recorded by api.RecordingTransport.
+// It lets the fetcher and storage layer run end-to-end without network access or an API key.
+package fakeapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// Server is an http.Handler implementing the subset of the Clash Royale API used by LogGob.
+// Data set with SetPlayer, SetBattleLog and SetCards takes precedence over fixtures.
+type Server struct {
+	mu         sync.Mutex
+	players    map[string]types.PlayerProfile
+	battleLogs map[string][]types.Battle
+	cards      []types.Card
+	fixtureDir string
+	requests   int
+
+	mux *http.ServeMux
+}
+
+// New creates an empty fake server.
+func New() *Server {
+	s := &Server{
+		players:    make(map[string]types.PlayerProfile),
+		battleLogs: make(map[string][]types.Battle),
+	}
+
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("GET /v1/players/{tag}", s.handlePlayer)
+	s.mux.HandleFunc("GET /v1/players/{tag}/battlelog", s.handleBattleLog)
+	s.mux.HandleFunc("GET /v1/cards", s.handleCards)
+	s.mux.HandleFunc("/", s.handleFixture)
+
+	return s
+}
+
+// NewFromDir creates a fake server that answers from fixtures recorded in dir.
+func NewFromDir(dir string) (*Server, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	s := New()
+	s.fixtureDir = dir
+	return s, nil
+}
+
+// Start serves s on a local httptest server. Callers must Close it.
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// SetPlayer makes /v1/players/{tag} return p.
+func (s *Server) SetPlayer(p types.PlayerProfile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.players[mustTag(p.Tag)] = p
+}
+
+// SetBattleLog makes /v1/players/{tag}/battlelog return battles.
+func (s *Server) SetBattleLog(tag string, battles []types.Battle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.battleLogs[mustTag(tag)] = battles
+}
+
+// SetCards makes /v1/cards return cards.
+func (s *Server) SetCards(cards []types.Card) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cards = cards
+}
+
+// Requests returns how many requests the server has handled.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	tag, ok := s.tag(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	p, found := s.players[tag]
+	s.mu.Unlock()
+
+	if !found {
+		s.handleFixture(w, r)
+		return
+	}
+	writeCacheable(w, r, p)
+}
+
+func (s *Server) handleBattleLog(w http.ResponseWriter, r *http.Request) {
+	tag, ok := s.tag(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	battles, found := s.battleLogs[tag]
+	s.mu.Unlock()
+
+	if !found {
+		s.handleFixture(w, r)
+		return
+	}
+	writeCacheable(w, r, battles)
+}
+
+func (s *Server) handleCards(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	cards := s.cards
+	s.mu.Unlock()
+
+	if cards == nil {
+		s.handleFixture(w, r)
+		return
+	}
+	writeCacheable(w, r, map[string][]types.Card{"items": cards})
+}
+
+// handleFixture serves a recorded fixture, or a notFound API error when there is none.
+// Successful fixtures are revalidated like live data, using the recorded ETag when present.
+func (s *Server) handleFixture(w http.ResponseWriter, r *http.Request) {
+	if s.fixtureDir == "" {
+		writeError(w, http.StatusNotFound, "notFound", "")
+		return
+	}
+
+	f, err := api.LoadFixture(s.fixtureDir, r.Method, r.URL)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "notFound", "")
+		return
+	}
+
+	resp := f.Response(r)
+	defer resp.Body.Close()
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Del("Content-Length")
+
+	if resp.StatusCode == http.StatusOK {
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			etag = etagFor(f.Body)
+			w.Header().Set("ETag", etag)
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tag extracts and validates the {tag} path value, writing a badRequest error if invalid.
+func (s *Server) tag(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tag, err := types.NormalizeTag(r.PathValue("tag"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "badRequest", err.Error())
+		return "", false
+	}
+	return tag, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeCacheable writes v with an ETag derived from its content, answering
+// 304 Not Modified when the request's If-None-Match already matches.
+func writeCacheable(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "unknownException", err.Error())
+		return
+	}
+
+	etag := etagFor(body)
+	w.Header().Set("ETag", etag)
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// etagFor derives a strong ETag from a response body.
+func etagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// writeError writes an error body in the same shape as the real API.
+func writeError(w http.ResponseWriter, status int, reason, message string) {
+	body := map[string]string{"reason": reason}
+	if message != "" {
+		body["message"] = message
+	}
+	writeJSON(w, status, body)
+}
+
+// mustTag normalizes tag, keeping it unchanged if it is not a valid tag so
+// tests can still register deliberately odd data.
+func mustTag(tag string) string {
+	if normalized, err := types.NormalizeTag(tag); err == nil {
+		return normalized
+	}
+	return tag
+}
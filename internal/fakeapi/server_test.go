@@ -0,0 +1,64 @@
+package fakeapi_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/api"
+	"github.com/elliot727/log-gob/internal/fakeapi"
+	"github.com/elliot727/log-gob/internal/storage"
+)
+
+// TestFetchAndStore runs a fetch end-to-end against recorded fixtures: the first
+// run stores the battle log, the second is answered 304 and stores nothing new.
+func TestFetchAndStore(t *testing.T) {
+	fake, err := fakeapi.NewFromDir("../../fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := fake.Start()
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := storage.NewStorage(db)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	client := api.New(srv.URL, "token", api.WithCache(t.TempDir()))
+	ctx := context.Background()
+
+	battles, _, err := client.BattleLog(ctx, "#2PP")
+	if err != nil {
+		t.Fatalf("first fetch: %v", err)
+	}
+	res, err := s.InsertBattles(ctx, battles)
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	if res.Inserted != len(battles) || res.Inserted == 0 {
+		t.Errorf("first run inserted %d of %d battles", res.Inserted, len(battles))
+	}
+
+	battles, src, err := client.BattleLog(ctx, "#2PP")
+	if err != nil || src != api.Revalidated {
+		t.Fatalf("second fetch: source %v, err %v; want revalidated", src, err)
+	}
+	res, err = s.InsertBattles(ctx, battles)
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	if res.Inserted != 0 || res.Duplicates != len(battles) {
+		t.Errorf("second run = %+v, want only duplicates", res)
+	}
+
+	if n := fake.Requests(); n != 2 {
+		t.Errorf("server saw %d requests, want 2", n)
+	}
+}
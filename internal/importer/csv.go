@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// requiredColumns are the CSV columns every file must have.
+var requiredColumns = []string{"battle_time", "player_tag", "opponent_tag", "player_crowns", "opponent_crowns"}
+
+// csvRow reads the columns of one CSV record by name.
+type csvRow struct {
+	cols   map[string]int
+	record []string
+}
+
+// get returns the named column, or "" if the file has no such column.
+func (r csvRow) get(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+// int32 parses the named column; an empty value is zero.
+func (r csvRow) int32(name string) (int32, error) {
+	v := r.get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid number %q", name, v)
+	}
+	return int32(n), nil
+}
+
+// ReadCSV decodes one 1v1 battle per row, seen from player's side. The first row
+// names the columns, in any order and any case. Unknown columns are ignored, so
+// exports with extra fields import as they are.
+//
+// Required columns:
+//
+//	battle_time      20251012T190501.000Z (the API's layout) or RFC 3339, e.g. 2025-10-12T19:05:01Z
+//	player_tag       with or without the leading '#'
+//	opponent_tag
+//	player_crowns
+//	opponent_crowns
+//
+// Optional columns:
+//
+//	type                        battle type, default "PvP"
+//	game_mode_id, game_mode_name
+//	arena_id, arena_name
+//	player_name, opponent_name
+//	player_starting_trophies, player_trophy_change
+//	opponent_starting_trophies, opponent_trophy_change
+//	player_cards, opponent_cards                  the deck
+//	player_support_cards, opponent_support_cards  tower troops
+//
+// Card lists are separated by ';'. Each card is its name or numeric ID, optionally
+// followed by ":level" and ":evolution level", e.g. "Knight:14:1;Hog Rider:11".
+// Levels are as the API reports them, relative to the card's rarity.
+//
+// cards resolves the names in card columns; it may be nil for files without them.
+func ReadCSV(r io.Reader, cards CardLookup) ([]types.Battle, []Problem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		cols[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := cols[name]; !ok {
+			return nil, nil, fmt.Errorf("missing required CSV column %q", name)
+		}
+	}
+
+	var battles []types.Battle
+	var problems []Problem
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				problems = append(problems, Problem{Where: fmt.Sprintf("line %d", parseErr.Line), Err: parseErr.Err})
+				continue
+			}
+			return battles, problems, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		b, err := csvBattle(csvRow{cols: cols, record: record}, cards)
+		if err == nil {
+			err = Validate(&b)
+		}
+		if err != nil {
+			problems = append(problems, Problem{Where: fmt.Sprintf("line %d", line), Err: err})
+			continue
+		}
+		battles = append(battles, b)
+	}
+
+	return battles, problems, nil
+}
+
+// csvBattle builds a battle from one CSV row.
+func csvBattle(row csvRow, cards CardLookup) (types.Battle, error) {
+	battleTime, err := parseBattleTime(row.get("battle_time"))
+	if err != nil {
+		return types.Battle{}, err
+	}
+
+	b := types.Battle{
+		BattleTime: battleTime,
+		BattleType: row.get("type"),
+		Arena:      types.Arena{Name: row.get("arena_name")},
+		GameMode:   types.GameMode{Name: row.get("game_mode_name")},
+	}
+	if b.BattleType == "" {
+		b.BattleType = "PvP"
+	}
+	if b.Arena.ID, err = row.int32("arena_id"); err != nil {
+		return b, err
+	}
+	if b.GameMode.ID, err = row.int32("game_mode_id"); err != nil {
+		return b, err
+	}
+
+	me, err := csvPlayer(row, "player_", cards)
+	if err != nil {
+		return b, err
+	}
+	opp, err := csvPlayer(row, "opponent_", cards)
+	if err != nil {
+		return b, err
+	}
+	b.Team = []types.Player{me}
+	b.Opponent = []types.Player{opp}
+
+	return b, nil
+}
+
+// csvPlayer builds one side of a CSV battle from the columns starting with prefix.
+func csvPlayer(row csvRow, prefix string, cards CardLookup) (types.Player, error) {
+	var p types.Player
+	var err error
+
+	if p.Tag, err = types.NormalizeTag(row.get(prefix + "tag")); err != nil {
+		return p, err
+	}
+	p.Name = row.get(prefix + "name")
+
+	if p.Crowns, err = row.int32(prefix + "crowns"); err != nil {
+		return p, err
+	}
+	if p.StartingTrophies, err = row.int32(prefix + "starting_trophies"); err != nil {
+		return p, err
+	}
+	if p.TrophyChange, err = row.int32(prefix + "trophy_change"); err != nil {
+		return p, err
+	}
+	if p.Cards, err = parseCards(row.get(prefix+"cards"), cards); err != nil {
+		return p, fmt.Errorf("%scards: %w", prefix, err)
+	}
+	if p.SupportCards, err = parseCards(row.get(prefix+"support_cards"), cards); err != nil {
+		return p, fmt.Errorf("%ssupport_cards: %w", prefix, err)
+	}
+
+	return p, nil
+}
+
+// parseCards parses a ';'-separated card list of "name[:level[:evolution level]]" entries.
+func parseCards(list string, lookup CardLookup) ([]types.Card, error) {
+	var cards []types.Card
+
+	for _, entry := range strings.Split(list, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if lookup == nil {
+			return nil, errors.New("card lists need a card lookup")
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid card %q", entry)
+		}
+
+		c, err := lookup(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		levels := []*int32{&c.Level, &c.EvolutionLevel}
+		for i, v := range parts[1:] {
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid level in card %q", entry)
+			}
+			*levels[i] = int32(n)
+		}
+
+		cards = append(cards, c)
+	}
+
+	return cards, nil
+}
+
+// parseBattleTime accepts the API's battle time layout or RFC 3339 and returns the API's layout.
+func parseBattleTime(s string) (string, error) {
+	if t, err := time.Parse(types.BattleTimeLayout, s); err == nil {
+		return t.Format(types.BattleTimeLayout), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "", fmt.Errorf("invalid battle time %q", s)
+	}
+	return t.UTC().Format(types.BattleTimeLayout), nil
+}
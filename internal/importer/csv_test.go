@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	const input = "battle_time,player_tag,opponent_tag,player_crowns,opponent_crowns\n" +
+		"20251012T190501.000Z,2PP,#8QQ,3,1\n" +
+		"2025-10-12T19:10:02Z,#2PP,#9RR,0,2\n" +
+		"yesterday,#2PP,#9RR,0,2\n"
+
+	battles, problems, err := ReadCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(battles) != 2 {
+		t.Fatalf("read %d battles, want 2", len(battles))
+	}
+	if got := battles[1].BattleTime; got != "20251012T191002.000Z" {
+		t.Errorf("RFC 3339 battle time read as %q", got)
+	}
+	if got := battles[0].Team[0].Tag; got != "#2PP" {
+		t.Errorf("player tag read as %q, want #2PP", got)
+	}
+	if len(problems) != 1 || problems[0].Where != "line 4" {
+		t.Errorf("problems = %v, want one on line 4", problems)
+	}
+}
+
+func TestReadCSVMalformedRow(t *testing.T) {
+	const input = "battle_time,player_tag,opponent_tag,player_crowns,opponent_crowns\n" +
+		"\"\n"
+
+	battles, problems, err := ReadCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(battles) != 0 {
+		t.Errorf("read %d battles from a malformed row", len(battles))
+	}
+	if len(problems) != 1 || problems[0].Where != "line 2" {
+		t.Errorf("problems = %v, want one on line 2", problems)
+	}
+}
@@ -0,0 +1,208 @@
+// Package importer reads battles exported outside LogGob: saved API battle logs,
+// NDJSON dumps and CSV exports from other trackers.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// Format is the layout of an import file.
+type Format string
+
+const (
+	Auto   Format = ""       // Detect from the file name and contents
+	JSON   Format = "json"   // A JSON array of battle log entries, as returned by /v1/players/{tag}/battlelog
+	NDJSON Format = "ndjson" // One battle log entry per line
+	CSV    Format = "csv"    // One battle per row; see ReadCSV for the columns
+)
+
+// ParseFormat parses a -format flag value. "auto" and "" mean Auto.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "auto":
+		return Auto, nil
+	case Auto, JSON, NDJSON, CSV:
+		return f, nil
+	}
+	return Auto, fmt.Errorf("unknown import format %q (available: json, ndjson, csv)", s)
+}
+
+// CardLookup resolves a card named in a CSV export, by name or numeric ID, to the card
+// it refers to. Only the card's identity and metadata are used; levels come from the file.
+type CardLookup func(ref string) (types.Card, error)
+
+// Problem is a record that could not be imported.
+type Problem struct {
+	Where string // e.g. "line 12" or "entry 3"
+	Err   error
+}
+
+func (p Problem) Error() string {
+	return fmt.Sprintf("%s: %v", p.Where, p.Err)
+}
+
+// Read decodes every battle in r. Records that fail to decode or validate are
+// returned as problems rather than stopping the import; the error is only set
+// when r cannot be read at all. name is used to detect the format when format is Auto.
+func Read(r io.Reader, name string, format Format, cards CardLookup) ([]types.Battle, []Problem, error) {
+	br := bufio.NewReader(r)
+
+	if format == Auto {
+		peek, _ := br.Peek(512)
+		format = DetectFormat(name, peek)
+	}
+
+	switch format {
+	case JSON:
+		return ReadJSON(br)
+	case NDJSON:
+		return ReadNDJSON(br)
+	case CSV:
+		return ReadCSV(br, cards)
+	}
+	return nil, nil, fmt.Errorf("unknown import format %q", format)
+}
+
+// DetectFormat guesses a file's format from its extension, falling back to its
+// first bytes: a JSON array starts with '[', anything else JSON-like is NDJSON.
+func DetectFormat(name string, peek []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSV
+	case ".ndjson", ".jsonl":
+		return NDJSON
+	}
+
+	switch trimmed := bytes.TrimLeft(peek, " \t\r\n\ufeff"); {
+	case len(trimmed) == 0:
+		return JSON
+	case trimmed[0] == '[':
+		return JSON
+	case trimmed[0] == '{':
+		return NDJSON
+	}
+	return CSV
+}
+
+// ReadJSON decodes a JSON array of battle log entries.
+func ReadJSON(r io.Reader) ([]types.Battle, []Problem, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, nil, errors.New("expected a JSON array of battles")
+	}
+
+	var battles []types.Battle
+	var problems []Problem
+
+	for n := 1; dec.More(); n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			// The stream is unusable past a syntax error
+			return battles, problems, fmt.Errorf("entry %d: %w", n, err)
+		}
+
+		b, err := decodeBattle(raw)
+		if err != nil {
+			problems = append(problems, Problem{Where: fmt.Sprintf("entry %d", n), Err: err})
+			continue
+		}
+		battles = append(battles, b)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return battles, problems, err
+	}
+
+	return battles, problems, nil
+}
+
+// ReadNDJSON decodes one battle log entry per line. Blank lines are skipped.
+func ReadNDJSON(r io.Reader) ([]types.Battle, []Problem, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var battles []types.Battle
+	var problems []Problem
+
+	for line := 1; sc.Scan(); line++ {
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		b, err := decodeBattle(data)
+		if err != nil {
+			problems = append(problems, Problem{Where: fmt.Sprintf("line %d", line), Err: err})
+			continue
+		}
+		battles = append(battles, b)
+	}
+
+	return battles, problems, sc.Err()
+}
+
+// decodeBattle decodes and validates one battle log entry, keeping its raw JSON for the archive.
+func decodeBattle(data []byte) (types.Battle, error) {
+	var b types.Battle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return b, err
+	}
+	return b, Validate(&b)
+}
+
+// Validate checks that b has what storage needs to file it: a valid battle time,
+// a type, and at least one tagged player on each side with identifiable cards.
+func Validate(b *types.Battle) error {
+	if _, err := b.Time(); err != nil {
+		return fmt.Errorf("invalid battle time %q", b.BattleTime)
+	}
+	if b.BattleType == "" {
+		return errors.New("missing battle type")
+	}
+	if len(b.Team) == 0 || len(b.Opponent) == 0 {
+		return errors.New("a battle needs players on both sides")
+	}
+
+	for _, side := range [][]types.Player{b.Team, b.Opponent} {
+		for _, p := range side {
+			if _, err := types.NormalizeTag(p.Tag); err != nil {
+				return err
+			}
+			if err := validateCards(p.Tag, p.Cards); err != nil {
+				return err
+			}
+			if err := validateCards(p.Tag, p.SupportCards); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateCards checks that every card in tag's deck has an ID to be stored under.
+func validateCards(tag string, cards []types.Card) error {
+	for _, c := range cards {
+		if c.ID == 0 {
+			return fmt.Errorf("card %q of %s has no ID", c.Name, tag)
+		}
+	}
+	return nil
+}
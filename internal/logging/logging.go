@@ -0,0 +1,100 @@
+// Package logging configures the structured logger shared by the CLI, the watch
+// daemon and the TUI, and carries request-scoped loggers through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Attribute keys used by every package, so logs can be filtered by player,
+// battle or ingestion run whichever layer wrote them.
+const (
+	KeyTag      = "tag"
+	KeyBattleID = "battle_id"
+	KeyRunID    = "run_id"
+)
+
+// Format selects the log handler.
+type Format string
+
+const (
+	Text Format = "text" // logfmt-style key=value lines
+	JSON Format = "json" // One JSON object per line
+)
+
+// ParseFormat parses a LOG_FORMAT value. An empty value means Text.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return Text, nil
+	case Text, JSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown log format %q (available: text, json)", s)
+}
+
+// ParseLevel parses a LOG_LEVEL value such as "debug", "info", "warn" or "error".
+// An empty value means info.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(s) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return level, fmt.Errorf("unknown log level %q (available: debug, info, warn, error)", s)
+	}
+	return level, nil
+}
+
+// New creates a logger writing records at level or above to w.
+func New(w io.Writer, format Format, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == JSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Setup installs the default logger, which also receives output from the log
+// package. Records go to file if set, appending to it, and to fallback otherwise.
+// The returned function closes the log file.
+func Setup(format Format, level slog.Leveler, file string, fallback io.Writer) (func() error, error) {
+	w, closeFn := fallback, func() error { return nil }
+
+	if file != "" {
+		if dir := filepath.Dir(file); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return nil, err
+			}
+		}
+		f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closeFn = f, f.Close
+	}
+
+	slog.SetDefault(New(w, format, level))
+	return closeFn, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, so code called with it logs with l's attributes.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
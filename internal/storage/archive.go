@@ -0,0 +1,169 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// reparseBatchSize is how many archived battles Reparse decodes at a time.
+const reparseBatchSize = 500
+
+// archive stores the gzip-compressed raw JSON of a battle. Battles without raw
+// JSON, and battles that are already archived, are left alone.
+func (w *battleWriter) archive(ctx context.Context, battleID string, raw json.RawMessage) error {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	payload, err := compress(raw)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.raw.ExecContext(ctx, battleID, time.Now().UTC().Format(time.RFC3339), payload)
+	return err
+}
+
+// RawBattle returns the battle log entry a battle was stored from, exactly as the
+// API returned it. It returns sql.ErrNoRows for battles stored before the archive existed.
+func (s *Storage) RawBattle(battleID string) (json.RawMessage, error) {
+	var payload []byte
+	err := s.DB.QueryRow(s.rebind("SELECT payload FROM raw_battles WHERE battle_id = ?"), battleID).Scan(&payload)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(payload)
+}
+
+// Reparse rebuilds the battles, participants and decks of every archived battle
+// from its raw JSON, so fields added to the types structs are filled in for battles
+// fetched before they existed. It returns how many battles were rebuilt.
+//
+// Everything happens in one transaction; on error nothing changes. Battles without
+// an archived payload are left as they are, and the ingestion filter is not applied.
+func (s *Storage) Reparse(ctx context.Context) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	w, err := newBattleWriter(ctx, tx, s.sqlDialect())
+	if err != nil {
+		return 0, err
+	}
+	defer w.close()
+
+	n := 0
+	after := ""
+	for {
+		batch, err := s.rawBattleBatch(ctx, tx, after)
+		if err != nil {
+			return 0, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, rb := range batch {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+
+			var b types.Battle
+			if err := json.Unmarshal(rb.payload, &b); err != nil {
+				return 0, fmt.Errorf("battle %s: %w", rb.battleID, err)
+			}
+
+			if err := s.deleteBattle(ctx, tx, rb.battleID); err != nil {
+				return 0, err
+			}
+			if _, err := w.write(ctx, &b); err != nil {
+				return 0, fmt.Errorf("battle %s: %w", rb.battleID, err)
+			}
+			n++
+		}
+
+		after = batch[len(batch)-1].battleID
+		logging.FromContext(ctx).Debug("Reparsed batch of archived battles", "battles", n, "last_battle_id", after)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// rawBattle is an archived battle with its payload decompressed.
+type rawBattle struct {
+	battleID string
+	payload  []byte
+}
+
+// rawBattleBatch returns the next batch of archived battles with IDs after the given one.
+func (s *Storage) rawBattleBatch(ctx context.Context, tx *sql.Tx, after string) ([]rawBattle, error) {
+	rows, err := tx.QueryContext(ctx,
+		s.rebind("SELECT battle_id, payload FROM raw_battles WHERE battle_id > ? ORDER BY battle_id LIMIT ?"),
+		after, reparseBatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []rawBattle
+	for rows.Next() {
+		var rb rawBattle
+		var payload []byte
+		if err := rows.Scan(&rb.battleID, &payload); err != nil {
+			return nil, err
+		}
+		if rb.payload, err = decompress(payload); err != nil {
+			return nil, fmt.Errorf("battle %s: %w", rb.battleID, err)
+		}
+		batch = append(batch, rb)
+	}
+	return batch, rows.Err()
+}
+
+// deleteBattle removes a battle's normalized rows, keeping its archived payload.
+func (s *Storage) deleteBattle(ctx context.Context, tx *sql.Tx, battleID string) error {
+	for _, table := range []string{"battle_decks", "battle_participants"} {
+		if _, err := tx.ExecContext(ctx, s.rebind("DELETE FROM "+table+" WHERE battle_id = ?"), battleID); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, s.rebind("DELETE FROM battles WHERE id = ?"), battleID)
+	return err
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
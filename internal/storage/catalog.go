@@ -0,0 +1,80 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// SyncCardCatalog upserts the full card list from /v1/cards into card_catalog.
+// The catalog takes precedence over the per-battle card snapshots in the cards
+// table whenever decks are loaded.
+func (s *Storage) SyncCardCatalog(cards []types.Card) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range cards {
+		_, err := tx.Exec(s.rebind(
+			`INSERT INTO card_catalog
+			 (id, name, rarity, elixir_cost, max_level, max_evolution_level, icon_url, evolution_icon_url, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (id) DO UPDATE SET
+			    name = excluded.name,
+			    rarity = excluded.rarity,
+			    elixir_cost = excluded.elixir_cost,
+			    max_level = excluded.max_level,
+			    max_evolution_level = excluded.max_evolution_level,
+			    icon_url = excluded.icon_url,
+			    evolution_icon_url = excluded.evolution_icon_url,
+			    updated_at = excluded.updated_at`),
+			c.ID, c.Name, c.Rarity, c.ElixirCost, c.MaxLevel, c.MaxEvolutionLevel,
+			c.IconURLs.Medium, c.IconURLs.EvolutionMedium, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetCardCatalog retrieves every card in the synced catalog, ordered by ID.
+func (s *Storage) GetCardCatalog() ([]types.Card, error) {
+	rows, err := s.DB.Query(`
+		SELECT id, name, rarity, elixir_cost, max_level, max_evolution_level, icon_url, evolution_icon_url
+		FROM card_catalog
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []types.Card
+
+	for rows.Next() {
+		var c types.Card
+		err := rows.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Rarity,
+			&c.ElixirCost,
+			&c.MaxLevel,
+			&c.MaxEvolutionLevel,
+			&c.IconURLs.Medium,
+			&c.IconURLs.EvolutionMedium,
+		)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
@@ -0,0 +1,158 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// CurrentRaceID identifies the river race in progress in river_race_participants.
+// Finished races are stored under RaceID(seasonID, sectionIndex) instead.
+const CurrentRaceID = "current"
+
+// RaceID builds the identifier of a finished river race from the river race log.
+func RaceID(seasonID, sectionIndex int32) string {
+	return fmt.Sprintf("S%d-W%d", seasonID, sectionIndex)
+}
+
+// SaveClan upserts a clan and replaces its member list with members.
+// Players who left the clan since the last sync are removed from clan_members.
+func (s *Storage) SaveClan(clan *types.Clan, members []types.ClanMember) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(s.rebind(
+		`INSERT INTO clans
+		 (tag, name, type, description, badge_id, clan_score, clan_war_trophies, required_trophies, members, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT (tag) DO UPDATE SET
+		    name = excluded.name,
+		    type = excluded.type,
+		    description = excluded.description,
+		    badge_id = excluded.badge_id,
+		    clan_score = excluded.clan_score,
+		    clan_war_trophies = excluded.clan_war_trophies,
+		    required_trophies = excluded.required_trophies,
+		    members = excluded.members,
+		    updated_at = excluded.updated_at`),
+		clan.Tag, clan.Name, clan.Type, clan.Description, clan.BadgeID,
+		clan.ClanScore, clan.ClanWarTrophies, clan.RequiredTrophies, clan.Members, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(s.rebind("DELETE FROM clan_members WHERE clan_tag = ?"), clan.Tag); err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		_, err := tx.Exec(
+			s.rebind("INSERT INTO players (tag, name) VALUES (?, ?) ON CONFLICT DO NOTHING"),
+			m.Tag, m.Name,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(s.rebind(
+			`INSERT INTO clan_members
+			 (clan_tag, player_tag, role, exp_level, trophies, clan_rank, donations, donations_received, last_seen, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			clan.Tag, m.Tag, m.Role, m.ExpLevel, m.Trophies, m.ClanRank,
+			m.Donations, m.DonationsReceived, m.LastSeen, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveRiverRace records each participant's contribution to a river race.
+// Use CurrentRaceID for the race in progress and RaceID for finished races.
+func (s *Storage) SaveRiverRace(clanTag, raceID string, participants []types.RiverRaceParticipant) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range participants {
+		_, err := tx.Exec(
+			s.rebind("INSERT INTO players (tag, name) VALUES (?, ?) ON CONFLICT DO NOTHING"),
+			p.Tag, p.Name,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(s.rebind(
+			`INSERT INTO river_race_participants
+			 (clan_tag, race_id, player_tag, fame, repair_points, boat_attacks, decks_used, decks_used_today, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 ON CONFLICT (clan_tag, race_id, player_tag) DO UPDATE SET
+			    fame = excluded.fame,
+			    repair_points = excluded.repair_points,
+			    boat_attacks = excluded.boat_attacks,
+			    decks_used = excluded.decks_used,
+			    decks_used_today = excluded.decks_used_today,
+			    updated_at = excluded.updated_at`),
+			clanTag, raceID, p.Tag, p.Fame, p.RepairPoints, p.BoatAttacks, p.DecksUsed, p.DecksUsedToday, now,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetClanMembers retrieves the stored members of a clan, ordered by clan rank.
+func (s *Storage) GetClanMembers(clanTag string) ([]types.ClanMember, error) {
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT cm.player_tag, p.name, cm.role, cm.exp_level, cm.trophies, cm.clan_rank,
+		       cm.donations, cm.donations_received, cm.last_seen
+		FROM clan_members cm
+		JOIN players p ON p.tag = cm.player_tag
+		WHERE cm.clan_tag = ?
+		ORDER BY cm.clan_rank
+	`), clanTag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []types.ClanMember
+
+	for rows.Next() {
+		var m types.ClanMember
+		err := rows.Scan(
+			&m.Tag,
+			&m.Name,
+			&m.Role,
+			&m.ExpLevel,
+			&m.Trophies,
+			&m.ClanRank,
+			&m.Donations,
+			&m.DonationsReceived,
+			&m.LastSeen,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
@@ -0,0 +1,74 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"strconv"
+	"strings"
+)
+
+// dialect captures the differences between the SQL databases Storage supports.
+// Queries are written once with "?" placeholders and portable upserts
+// (INSERT ... ON CONFLICT), which both SQLite and PostgreSQL understand.
+type dialect struct {
+	name string
+
+	// numbered rewrites "?" placeholders as $1, $2, ... before queries run
+	numbered bool
+
+	// rowOrder is the battle_participants column that orders rows by insertion
+	rowOrder string
+
+	// tableExists counts the tables in the current schema named by its one argument
+	tableExists string
+}
+
+var (
+	sqliteDialect = &dialect{
+		name:        "sqlite",
+		rowOrder:    "rowid",
+		tableExists: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+	}
+
+	postgresDialect = &dialect{
+		name:        "postgres",
+		numbered:    true,
+		rowOrder:    "seq",
+		tableExists: "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?",
+	}
+)
+
+// migrations lists the schema history of databases using the dialect.
+func (d *dialect) migrations() []Migration {
+	if d == postgresDialect {
+		return postgresMigrations
+	}
+	return sqliteMigrations
+}
+
+// rebind rewrites query's "?" placeholders for the dialect. Queries must not
+// contain a literal "?" outside placeholders.
+func (d *dialect) rebind(query string) string {
+	if !d.numbered || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// falseLiteral returns the SQL literal stored for false in boolean columns.
+func (d *dialect) falseLiteral() string {
+	if d == postgresDialect {
+		return "FALSE"
+	}
+	return "0"
+}
@@ -0,0 +1,80 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// LadderGameModeID is the game mode ID of Ladder (trophy road) battles.
+const LadderGameModeID = 72000006
+
+// ErrFiltered is returned by InsertBattle for battles rejected by the ingestion filter.
+var ErrFiltered = errors.New("battle excluded by ingestion filter")
+
+// BattleFilter selects battles by battle type and game mode.
+// Empty fields match everything, so the zero value matches every battle.
+type BattleFilter struct {
+	Types       []string // Battle types, e.g. "PvP", "pathOfLegend", "challenge", "clanWarWarDay"
+	GameModeIDs []int32  // Game mode IDs, e.g. LadderGameModeID
+}
+
+// Predefined filters.
+var (
+	AllBattles    = BattleFilter{}
+	LadderBattles = BattleFilter{Types: []string{"PvP"}, GameModeIDs: []int32{LadderGameModeID}}
+)
+
+// Match reports whether b passes the filter.
+func (f BattleFilter) Match(b *types.Battle) bool {
+	if len(f.Types) > 0 && !contains(f.Types, b.BattleType) {
+		return false
+	}
+	if len(f.GameModeIDs) > 0 && !contains(f.GameModeIDs, b.GameMode.ID) {
+		return false
+	}
+	return true
+}
+
+// IsAll reports whether the filter matches every battle.
+func (f BattleFilter) IsAll() bool {
+	return len(f.Types) == 0 && len(f.GameModeIDs) == 0
+}
+
+// where renders the filter as SQL conditions on the battles table aliased as
+// alias, each prefixed with AND, along with their arguments.
+func (f BattleFilter) where(alias string) (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+
+	if len(f.Types) > 0 {
+		sb.WriteString(" AND " + alias + ".type IN (" + placeholders(len(f.Types)) + ")")
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	if len(f.GameModeIDs) > 0 {
+		sb.WriteString(" AND " + alias + ".gamemode_id IN (" + placeholders(len(f.GameModeIDs)) + ")")
+		for _, id := range f.GameModeIDs {
+			args = append(args, id)
+		}
+	}
+
+	return sb.String(), args
+}
+
+// placeholders returns n comma-separated "?" placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
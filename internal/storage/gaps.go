@@ -0,0 +1,69 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"time"
+)
+
+// IngestionGap records that battles may be missing from a player's history.
+// The API only keeps a player's last 25 battles, so when the oldest battle in a
+// full fetched log is newer than the newest one seen before, anything played in
+// between was never seen.
+type IngestionGap struct {
+	PlayerTag  string
+	After      string // BattleTime of the newest battle seen before the fetch
+	Before     string // BattleTime of the oldest battle in the fetched log
+	DetectedAt time.Time
+}
+
+// NewestBattleTime returns the BattleTime of the player's most recent stored battle,
+// or "" if none is stored.
+func (s *Storage) NewestBattleTime(tag string) (string, error) {
+	var newest string
+	err := s.DB.QueryRow(s.rebind(`
+		SELECT COALESCE(MAX(b.battleTime), '')
+		FROM battles b
+		JOIN battle_participants bp ON bp.battle_id = b.id
+		WHERE bp.player_tag = ?
+	`), tag).Scan(&newest)
+	return newest, err
+}
+
+// RecordGap saves a detected gap. Recording the same gap twice has no effect.
+func (s *Storage) RecordGap(g IngestionGap) error {
+	_, err := s.DB.Exec(s.rebind(`
+		INSERT INTO ingestion_gaps (player_tag, after_battle, before_battle, detected_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`), g.PlayerTag, g.After, g.Before, g.DetectedAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// IngestionGaps returns the gaps recorded for a player, most recent first.
+// An empty tag returns the gaps of every player.
+func (s *Storage) IngestionGaps(tag string) ([]IngestionGap, error) {
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT player_tag, after_battle, before_battle, detected_at
+		FROM ingestion_gaps
+		WHERE ? = '' OR player_tag = ?
+		ORDER BY before_battle DESC
+	`), tag, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gaps []IngestionGap
+
+	for rows.Next() {
+		var g IngestionGap
+		var detectedAt string
+		if err := rows.Scan(&g.PlayerTag, &g.After, &g.Before, &detectedAt); err != nil {
+			return nil, err
+		}
+		g.DetectedAt, _ = time.Parse(time.RFC3339, detectedAt)
+		gaps = append(gaps, g)
+	}
+
+	return gaps, rows.Err()
+}
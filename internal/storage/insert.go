@@ -0,0 +1,232 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/elliot727/log-gob/internal/logging"
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// InsertResult counts what InsertBattles did with each battle it was given.
+type InsertResult struct {
+	Inserted   int // New battles written to the database
+	Duplicates int // Battles that were already stored
+	Skipped    int // Battles rejected by the ingestion filter
+}
+
+// InsertBattles saves battles and their arenas, game modes, players, cards,
+// participants and decks in a single transaction using prepared statements.
+// Battles rejected by s.Ingest are skipped and battles already stored are left untouched.
+// On error, including ctx being cancelled, nothing is written and the result is zero.
+func (s *Storage) InsertBattles(ctx context.Context, battles []types.Battle) (InsertResult, error) {
+	var res InsertResult
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return InsertResult{}, err
+	}
+	defer tx.Rollback()
+
+	w, err := newBattleWriter(ctx, tx, s.sqlDialect())
+	if err != nil {
+		return InsertResult{}, err
+	}
+	defer w.close()
+
+	logger := logging.FromContext(ctx)
+
+	for i := range battles {
+		if err := ctx.Err(); err != nil {
+			return InsertResult{}, err
+		}
+
+		b := &battles[i]
+		if !s.Ingest.Match(b) {
+			res.Skipped++
+			logger.Debug("Battle skipped by the ingestion filter", logging.KeyBattleID, b.ID(), "type", b.BattleType, "game_mode", b.GameMode.ID)
+			continue
+		}
+
+		inserted, err := w.write(ctx, b)
+		if err != nil {
+			return InsertResult{}, fmt.Errorf("battle %s: %w", b.ID(), err)
+		}
+		if inserted {
+			res.Inserted++
+			logger.Debug("Stored battle", logging.KeyBattleID, b.ID(), "time", b.BattleTime)
+		} else {
+			res.Duplicates++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return InsertResult{}, err
+	}
+	return res, nil
+}
+
+// upsertCardSQL records a card in the cards snapshot table, filling in evolution
+// and icon details that earlier snapshots lacked.
+const upsertCardSQL = `INSERT INTO cards (id, name, maxLevel, rarity, elixirCost, max_evolution_level, icon_url, evolution_icon_url)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET
+		max_evolution_level = CASE
+			WHEN excluded.max_evolution_level > cards.max_evolution_level THEN excluded.max_evolution_level
+			ELSE cards.max_evolution_level
+		END,
+		icon_url = COALESCE(NULLIF(excluded.icon_url, ''), cards.icon_url),
+		evolution_icon_url = COALESCE(NULLIF(excluded.evolution_icon_url, ''), cards.evolution_icon_url)`
+
+// battleWriter holds the prepared statements used to write battles within a transaction.
+type battleWriter struct {
+	arena       *sql.Stmt
+	gameMode    *sql.Stmt
+	battle      *sql.Stmt
+	player      *sql.Stmt
+	card        *sql.Stmt
+	participant *sql.Stmt
+	deckCard    *sql.Stmt
+	raw         *sql.Stmt
+}
+
+func newBattleWriter(ctx context.Context, tx *sql.Tx, d *dialect) (*battleWriter, error) {
+	w := &battleWriter{}
+
+	stmts := []struct {
+		dst   **sql.Stmt
+		query string
+	}{
+		{&w.arena, "INSERT INTO arenas (id, name) VALUES (?, ?) ON CONFLICT DO NOTHING"},
+		{&w.gameMode, "INSERT INTO gamemodes (id, name) VALUES (?, ?) ON CONFLICT DO NOTHING"},
+		{&w.battle, "INSERT INTO battles (id, battleTime, type, arena_id, gamemode_id) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING"},
+		{&w.player, "INSERT INTO players (tag, name) VALUES (?, ?) ON CONFLICT DO NOTHING"},
+		{&w.card, upsertCardSQL},
+		{&w.participant, `INSERT INTO battle_participants
+			(battle_id, player_tag, role, crowns, startingTrophies, trophyChange, elixirLeaked, deck_hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (battle_id, player_tag) DO UPDATE SET
+				role = excluded.role,
+				crowns = excluded.crowns,
+				startingTrophies = excluded.startingTrophies,
+				trophyChange = excluded.trophyChange,
+				elixirLeaked = excluded.elixirLeaked,
+				deck_hash = excluded.deck_hash`},
+		{&w.deckCard, `INSERT INTO battle_decks
+			(battle_id, player_tag, support, slot, card_id, card_level, evolution_level, star_level)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (battle_id, player_tag, support, slot) DO UPDATE SET
+				card_id = excluded.card_id,
+				card_level = excluded.card_level,
+				evolution_level = excluded.evolution_level,
+				star_level = excluded.star_level`},
+		{&w.raw, "INSERT INTO raw_battles (battle_id, fetched_at, payload) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"},
+	}
+
+	for _, st := range stmts {
+		stmt, err := tx.PrepareContext(ctx, d.rebind(st.query))
+		if err != nil {
+			w.close()
+			return nil, err
+		}
+		*st.dst = stmt
+	}
+
+	return w, nil
+}
+
+// write saves b and reports whether it was new. Participants and decks of a
+// battle that is already stored are not rewritten, but its raw JSON is archived
+// if it wasn't already.
+func (w *battleWriter) write(ctx context.Context, b *types.Battle) (bool, error) {
+	if _, err := w.arena.ExecContext(ctx, b.Arena.ID, b.Arena.Name); err != nil {
+		return false, err
+	}
+	if _, err := w.gameMode.ExecContext(ctx, b.GameMode.ID, b.GameMode.Name); err != nil {
+		return false, err
+	}
+
+	battleID := b.ID()
+
+	r, err := w.battle.ExecContext(ctx, battleID, b.BattleTime, b.BattleType, b.Arena.ID, b.GameMode.ID)
+	if err != nil {
+		return false, err
+	}
+	if err := w.archive(ctx, battleID, b.Raw); err != nil {
+		return false, err
+	}
+	if n, err := r.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	for _, p := range b.Team {
+		if err := w.writeParticipant(ctx, battleID, "team", &p); err != nil {
+			return false, err
+		}
+	}
+	for _, p := range b.Opponent {
+		if err := w.writeParticipant(ctx, battleID, "opponent", &p); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// writeParticipant saves a player, their deck and support cards, and their part in the battle.
+func (w *battleWriter) writeParticipant(ctx context.Context, battleID, role string, p *types.Player) error {
+	if _, err := w.player.ExecContext(ctx, p.Tag, p.Name); err != nil {
+		return err
+	}
+
+	_, err := w.participant.ExecContext(ctx,
+		battleID,
+		p.Tag,
+		role,
+		p.Crowns,
+		int64(p.StartingTrophies),
+		int64(p.TrophyChange),
+		p.ElixirLeaked,
+		p.DeckHash(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writeDeck(ctx, battleID, p.Tag, false, p.Cards); err != nil {
+		return err
+	}
+	return w.writeDeck(ctx, battleID, p.Tag, true, p.SupportCards)
+}
+
+// writeDeck saves cards in deck order, recording each card's slot.
+func (w *battleWriter) writeDeck(ctx context.Context, battleID, tag string, support bool, cards []types.Card) error {
+	for slot, c := range cards {
+		_, err := w.card.ExecContext(ctx,
+			c.ID, c.Name, c.MaxLevel, c.Rarity, c.ElixirCost,
+			c.MaxEvolutionLevel, c.IconURLs.Medium, c.IconURLs.EvolutionMedium,
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.deckCard.ExecContext(ctx,
+			battleID, tag, support, slot, c.ID, c.Level, c.EvolutionLevel, c.StarLevel,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *battleWriter) close() {
+	for _, stmt := range []*sql.Stmt{w.arena, w.gameMode, w.battle, w.player, w.card, w.participant, w.deckCard, w.raw} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
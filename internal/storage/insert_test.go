@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+func TestInsertBattlesDedupes(t *testing.T) {
+	s := newTestStorage(t)
+	ctx := context.Background()
+
+	battles := []types.Battle{
+		testBattle("20251012T190501.000Z", "#2PP", "#8QQ", 3, 1),
+		testBattle("20251012T191002.000Z", "#2PP", "#9RR", 0, 2),
+	}
+
+	res, err := s.InsertBattles(ctx, battles)
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	if want := (InsertResult{Inserted: 2}); res != want {
+		t.Errorf("first insert = %+v, want %+v", res, want)
+	}
+
+	// The same battle seen from the opponent's side has the same ID.
+	swapped := battles[0]
+	swapped.Team, swapped.Opponent = swapped.Opponent, swapped.Team
+	res, err = s.InsertBattles(ctx, append(battles, swapped))
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	if want := (InsertResult{Duplicates: 3}); res != want {
+		t.Errorf("second insert = %+v, want %+v", res, want)
+	}
+
+	stored, err := s.GetBattlesForPlayer("#2PP", AllBattles)
+	if err != nil {
+		t.Fatalf("GetBattlesForPlayer: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("stored %d battles, want 2", len(stored))
+	}
+	for _, b := range stored {
+		if len(b.Team) != 1 || len(b.Opponent) != 1 || len(b.Team[0].Cards) != 8 {
+			t.Errorf("battle %s loaded with %d team, %d opponent players", b.BattleTime, len(b.Team), len(b.Opponent))
+		}
+	}
+}
+
+func TestInsertBattlesSkipsFiltered(t *testing.T) {
+	s := newTestStorage(t)
+	s.Ingest = LadderBattles
+
+	friendly := testBattle("20251012T190501.000Z", "#2PP", "#8QQ", 1, 0)
+	friendly.BattleType = "friendly"
+
+	res, err := s.InsertBattles(context.Background(), []types.Battle{friendly})
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	if want := (InsertResult{Skipped: 1}); res != want {
+		t.Errorf("insert = %+v, want %+v", res, want)
+	}
+}
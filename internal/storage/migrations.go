@@ -0,0 +1,608 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// Migration is a single versioned schema change. Up runs inside a transaction
+// that also records the version in schema_migrations, so a step is either
+// applied completely or not at all.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// MigrationStatus reports whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time // Zero when not applied
+}
+
+// sqliteMigrations lists every SQLite schema change in the order it must be applied.
+// Versions are never renumbered or edited once released; add a new step instead,
+// along with its counterpart in postgresMigrations.
+//
+// Databases created before schema_migrations existed have no recorded versions,
+// so every step must also be safe to run against tables that already exist.
+var sqliteMigrations = []Migration{
+	{Version: 1, Name: "create battle tables", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS arenas (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS gamemodes (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS players (
+			tag TEXT PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS cards (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			maxLevel INTEGER NOT NULL,
+			rarity TEXT NOT NULL,
+			elixirCost INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS battles (
+			battleTime TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			arena_id INTEGER NOT NULL,
+			gamemode_id INTEGER NOT NULL,
+			FOREIGN KEY (arena_id) REFERENCES arenas(id),
+			FOREIGN KEY (gamemode_id) REFERENCES gamemodes(id)
+		);
+		CREATE TABLE IF NOT EXISTS battle_participants (
+			battleTime TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			role TEXT NOT NULL,
+			crowns INTEGER NOT NULL,
+			startingTrophies INTEGER NOT NULL,
+			trophyChange INTEGER NOT NULL,
+			elixirLeaked REAL NOT NULL,
+			PRIMARY KEY (battleTime, player_tag),
+			FOREIGN KEY (battleTime) REFERENCES battles(battleTime) ON DELETE CASCADE,
+			FOREIGN KEY (player_tag) REFERENCES players(tag)
+		);
+		CREATE TABLE IF NOT EXISTS battle_decks (
+			battleTime TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			card_id INTEGER NOT NULL,
+			card_level INTEGER NOT NULL,
+			PRIMARY KEY (battleTime, player_tag, card_id),
+			FOREIGN KEY (battleTime, player_tag) REFERENCES battle_participants(battleTime, player_tag) ON DELETE CASCADE,
+			FOREIGN KEY (card_id) REFERENCES cards(id)
+		);
+	`)},
+	{Version: 2, Name: "create clan tables", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS clans (
+			tag TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			description TEXT NOT NULL,
+			badge_id INTEGER NOT NULL,
+			clan_score INTEGER NOT NULL,
+			clan_war_trophies INTEGER NOT NULL,
+			required_trophies INTEGER NOT NULL,
+			members INTEGER NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS clan_members (
+			clan_tag TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			role TEXT NOT NULL,
+			exp_level INTEGER NOT NULL,
+			trophies INTEGER NOT NULL,
+			clan_rank INTEGER NOT NULL,
+			donations INTEGER NOT NULL,
+			donations_received INTEGER NOT NULL,
+			last_seen TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (clan_tag, player_tag),
+			FOREIGN KEY (clan_tag) REFERENCES clans(tag) ON DELETE CASCADE,
+			FOREIGN KEY (player_tag) REFERENCES players(tag)
+		);
+		CREATE TABLE IF NOT EXISTS river_race_participants (
+			clan_tag TEXT NOT NULL,
+			race_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			fame INTEGER NOT NULL,
+			repair_points INTEGER NOT NULL,
+			boat_attacks INTEGER NOT NULL,
+			decks_used INTEGER NOT NULL,
+			decks_used_today INTEGER NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (clan_tag, race_id, player_tag),
+			FOREIGN KEY (player_tag) REFERENCES players(tag)
+		);
+	`)},
+	{Version: 3, Name: "create card catalog", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS card_catalog (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			rarity TEXT NOT NULL,
+			elixir_cost INTEGER NOT NULL,
+			max_level INTEGER NOT NULL,
+			max_evolution_level INTEGER NOT NULL,
+			icon_url TEXT NOT NULL,
+			evolution_icon_url TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+	`)},
+	{Version: 4, Name: "key battles by battle ID", Up: migrateBattleIDs},
+	{Version: 5, Name: "store deck slots, support cards, evolutions and star levels", Up: migrateDeckSlots},
+	{Version: 6, Name: "create raw battle archive", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS raw_battles (
+			battle_id TEXT PRIMARY KEY,
+			fetched_at TEXT NOT NULL,
+			payload BLOB NOT NULL
+		);
+	`)},
+	{Version: 7, Name: "index battle queries", Up: func(tx *sql.Tx) error {
+		return indexBattleQueries(tx, sqliteDialect)
+	}},
+	{Version: 8, Name: "create player snapshots", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS player_snapshots (
+			player_tag TEXT NOT NULL,
+			taken_at TEXT NOT NULL,
+			name TEXT NOT NULL,
+			exp_level INTEGER NOT NULL,
+			exp_points INTEGER NOT NULL,
+			trophies INTEGER NOT NULL,
+			best_trophies INTEGER NOT NULL,
+			wins INTEGER NOT NULL,
+			losses INTEGER NOT NULL,
+			battle_count INTEGER NOT NULL,
+			three_crown_wins INTEGER NOT NULL,
+			star_points INTEGER NOT NULL,
+			arena_id INTEGER NOT NULL,
+			clan_tag TEXT NOT NULL,
+			PRIMARY KEY (player_tag, taken_at),
+			FOREIGN KEY (player_tag) REFERENCES players(tag),
+			FOREIGN KEY (arena_id) REFERENCES arenas(id)
+		);
+		CREATE TABLE IF NOT EXISTS player_snapshot_cards (
+			player_tag TEXT NOT NULL,
+			taken_at TEXT NOT NULL,
+			support INTEGER NOT NULL,
+			card_id INTEGER NOT NULL,
+			level INTEGER NOT NULL,
+			star_level INTEGER NOT NULL,
+			evolution_level INTEGER NOT NULL,
+			count INTEGER NOT NULL,
+			PRIMARY KEY (player_tag, taken_at, support, card_id),
+			FOREIGN KEY (player_tag, taken_at) REFERENCES player_snapshots(player_tag, taken_at) ON DELETE CASCADE,
+			FOREIGN KEY (card_id) REFERENCES cards(id)
+		);
+	`)},
+	{Version: 9, Name: "create ingestion gaps", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS ingestion_gaps (
+			player_tag TEXT NOT NULL,
+			after_battle TEXT NOT NULL,
+			before_battle TEXT NOT NULL,
+			detected_at TEXT NOT NULL,
+			PRIMARY KEY (player_tag, after_battle, before_battle)
+		);
+	`)},
+	{Version: 10, Name: "create ingestion runs", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS ingestion_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			player_tag TEXT NOT NULL,
+			started_at TEXT NOT NULL,
+			finished_at TEXT NOT NULL DEFAULT '',
+			api_status INTEGER NOT NULL DEFAULT 0,
+			fetched INTEGER NOT NULL DEFAULT 0,
+			inserted INTEGER NOT NULL DEFAULT 0,
+			duplicates INTEGER NOT NULL DEFAULT 0,
+			skipped INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_ingestion_runs_player ON ingestion_runs(player_tag, started_at);
+	`)},
+	{Version: 11, Name: "record the newest battle seen by each ingestion run", Up: execStep(`
+		ALTER TABLE ingestion_runs ADD COLUMN latest_battle TEXT NOT NULL DEFAULT '';
+	`)},
+}
+
+// Migrations returns every known migration for the database in order.
+func (s *Storage) Migrations() []Migration {
+	return append([]Migration(nil), s.sqlDialect().migrations()...)
+}
+
+// Migrate applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied. It stops at the first failure, leaving the
+// database at the last successfully applied version.
+func (s *Storage) Migrate() ([]Migration, error) {
+	if err := s.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, m := range s.sqlDialect().migrations() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := s.apply(m); err != nil {
+			return ran, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		slog.Info("Applied migration", "version", m.Version, "name", m.Name, "backend", s.sqlDialect().name)
+		ran = append(ran, m)
+	}
+
+	return ran, nil
+}
+
+// MigrationStatus reports every known migration and whether it has been applied,
+// without changing the database.
+func (s *Storage) MigrationStatus() ([]MigrationStatus, error) {
+	var applied map[int]time.Time
+
+	var tables int
+	if err := s.DB.QueryRow(s.rebind(s.sqlDialect().tableExists), "schema_migrations").Scan(&tables); err != nil {
+		return nil, err
+	}
+	if tables > 0 {
+		var err error
+		if applied, err = s.appliedMigrations(); err != nil {
+			return nil, err
+		}
+	}
+
+	migrations := s.sqlDialect().migrations()
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Version]
+		status = append(status, MigrationStatus{Migration: m, Applied: ok, AppliedAt: at})
+	}
+	return status, nil
+}
+
+// apply runs m and records it in one transaction.
+func (s *Storage) apply(m Migration) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.Up(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		s.rebind("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)"),
+		m.Version, m.Name, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) ensureMigrationsTable() error {
+	_, err := s.DB.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`)
+	return err
+}
+
+// appliedMigrations returns the time each recorded version was applied.
+func (s *Storage) appliedMigrations() (map[int]time.Time, error) {
+	rows, err := s.DB.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		at, _ := time.Parse(time.RFC3339, appliedAt)
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// execStep returns a migration step that executes a fixed SQL script.
+func execStep(script string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(script)
+		return err
+	}
+}
+
+// migrateBattleIDs rebuilds the battle tables around types.BattleID. Battles were
+// previously keyed by battleTime alone, so two battles finishing in the same
+// second overwrote each other. Databases that already have battles.id are left alone.
+func migrateBattleIDs(tx *sql.Tx) error {
+	migrated, err := hasColumn(tx, "battles", "id")
+	if err != nil || migrated {
+		return err
+	}
+
+	for _, table := range []string{"battle_decks", "battle_participants", "battles"} {
+		if _, err := tx.Exec("ALTER TABLE " + table + " RENAME TO legacy_" + table); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		CREATE TABLE battles (
+			id TEXT PRIMARY KEY,
+			battleTime TEXT NOT NULL,
+			type TEXT NOT NULL,
+			arena_id INTEGER NOT NULL,
+			gamemode_id INTEGER NOT NULL,
+			FOREIGN KEY (arena_id) REFERENCES arenas(id),
+			FOREIGN KEY (gamemode_id) REFERENCES gamemodes(id)
+		);
+		CREATE INDEX idx_battles_battleTime ON battles (battleTime);
+		CREATE TABLE battle_participants (
+			battle_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			role TEXT NOT NULL,
+			crowns INTEGER NOT NULL,
+			startingTrophies INTEGER NOT NULL,
+			trophyChange INTEGER NOT NULL,
+			elixirLeaked REAL NOT NULL,
+			PRIMARY KEY (battle_id, player_tag),
+			FOREIGN KEY (battle_id) REFERENCES battles(id) ON DELETE CASCADE,
+			FOREIGN KEY (player_tag) REFERENCES players(tag)
+		);
+		CREATE INDEX idx_battle_participants_player ON battle_participants (player_tag);
+		CREATE TABLE battle_decks (
+			battle_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			card_id INTEGER NOT NULL,
+			card_level INTEGER NOT NULL,
+			PRIMARY KEY (battle_id, player_tag, card_id),
+			FOREIGN KEY (battle_id, player_tag) REFERENCES battle_participants(battle_id, player_tag) ON DELETE CASCADE,
+			FOREIGN KEY (card_id) REFERENCES cards(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	ids, err := legacyBattleIDs(tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("CREATE TEMP TABLE battle_id_map (battleTime TEXT PRIMARY KEY, id TEXT NOT NULL)"); err != nil {
+		return err
+	}
+	for battleTime, id := range ids {
+		if _, err := tx.Exec("INSERT INTO battle_id_map (battleTime, id) VALUES (?, ?)", battleTime, id); err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		`INSERT INTO battles (id, battleTime, type, arena_id, gamemode_id)
+		 SELECT m.id, lb.battleTime, lb.type, lb.arena_id, lb.gamemode_id
+		 FROM legacy_battles lb JOIN battle_id_map m ON m.battleTime = lb.battleTime`,
+		`INSERT INTO battle_participants (battle_id, player_tag, role, crowns, startingTrophies, trophyChange, elixirLeaked)
+		 SELECT m.id, lp.player_tag, lp.role, lp.crowns, lp.startingTrophies, lp.trophyChange, lp.elixirLeaked
+		 FROM legacy_battle_participants lp JOIN battle_id_map m ON m.battleTime = lp.battleTime`,
+		`INSERT INTO battle_decks (battle_id, player_tag, card_id, card_level)
+		 SELECT m.id, ld.player_tag, ld.card_id, ld.card_level
+		 FROM legacy_battle_decks ld JOIN battle_id_map m ON m.battleTime = ld.battleTime`,
+		"DROP TABLE battle_id_map",
+		"DROP TABLE legacy_battle_decks",
+		"DROP TABLE legacy_battle_participants",
+		"DROP TABLE legacy_battles",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// legacyBattleIDs computes the new ID of every battle in legacy_battles from its
+// time and the participants recorded for it.
+func legacyBattleIDs(tx *sql.Tx) (map[string]string, error) {
+	tags := make(map[string][]string)
+
+	rows, err := tx.Query(`
+		SELECT lb.battleTime, lp.player_tag
+		FROM legacy_battles lb
+		LEFT JOIN legacy_battle_participants lp ON lp.battleTime = lb.battleTime
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var battleTime string
+		var tag sql.NullString
+		if err := rows.Scan(&battleTime, &tag); err != nil {
+			return nil, err
+		}
+		if tag.Valid {
+			tags[battleTime] = append(tags[battleTime], tag.String)
+		} else if _, ok := tags[battleTime]; !ok {
+			tags[battleTime] = nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(tags))
+	for battleTime, t := range tags {
+		ids[battleTime] = types.BattleID(battleTime, t)
+	}
+	return ids, nil
+}
+
+// migrateDeckSlots rebuilds battle_decks with the position of each card in the deck,
+// a flag for support cards (the tower troop), and the evolution and star level the
+// card was played at. Existing rows are numbered in the order they were inserted,
+// which is the order the battle log listed them. It also adds icon and evolution
+// columns to the cards snapshot table.
+func migrateDeckSlots(tx *sql.Tx) error {
+	for _, col := range []string{
+		"max_evolution_level INTEGER NOT NULL DEFAULT 0",
+		"icon_url TEXT NOT NULL DEFAULT ''",
+		"evolution_icon_url TEXT NOT NULL DEFAULT ''",
+	} {
+		name, _, _ := strings.Cut(col, " ")
+		exists, err := hasColumn(tx, "cards", name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := tx.Exec("ALTER TABLE cards ADD COLUMN " + col); err != nil {
+				return err
+			}
+		}
+	}
+
+	migrated, err := hasColumn(tx, "battle_decks", "slot")
+	if err != nil || migrated {
+		return err
+	}
+
+	statements := []string{
+		"ALTER TABLE battle_decks RENAME TO legacy_battle_decks",
+		`CREATE TABLE battle_decks (
+			battle_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			support INTEGER NOT NULL DEFAULT 0,
+			slot INTEGER NOT NULL,
+			card_id INTEGER NOT NULL,
+			card_level INTEGER NOT NULL,
+			evolution_level INTEGER NOT NULL DEFAULT 0,
+			star_level INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (battle_id, player_tag, support, slot),
+			FOREIGN KEY (battle_id, player_tag) REFERENCES battle_participants(battle_id, player_tag) ON DELETE CASCADE,
+			FOREIGN KEY (card_id) REFERENCES cards(id)
+		)`,
+		`INSERT INTO battle_decks (battle_id, player_tag, support, slot, card_id, card_level)
+		 SELECT battle_id, player_tag, 0,
+		        ROW_NUMBER() OVER (PARTITION BY battle_id, player_tag ORDER BY rowid) - 1,
+		        card_id, card_level
+		 FROM legacy_battle_decks`,
+		"DROP TABLE legacy_battle_decks",
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexBattleQueries adds the deck_hash column to battle_participants, fills it in
+// from battle_decks, and creates the indexes BattleQuery relies on.
+func indexBattleQueries(tx *sql.Tx, d *dialect) error {
+	exists := false
+	if d == sqliteDialect {
+		var err error
+		if exists, err = hasColumn(tx, "battle_participants", "deck_hash"); err != nil {
+			return err
+		}
+	}
+	if !exists {
+		// PostgreSQL supports IF NOT EXISTS here, SQLite does not
+		add := "ALTER TABLE battle_participants ADD COLUMN deck_hash TEXT NOT NULL DEFAULT ''"
+		if d == postgresDialect {
+			add = "ALTER TABLE battle_participants ADD COLUMN IF NOT EXISTS deck_hash TEXT NOT NULL DEFAULT ''"
+		}
+		if _, err := tx.Exec(add); err != nil {
+			return err
+		}
+	}
+
+	if err := backfillDeckHashes(tx, d); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_battles_gamemode_time ON battles (gamemode_id, battleTime);
+		CREATE INDEX IF NOT EXISTS idx_battles_arena ON battles (arena_id);
+		CREATE INDEX IF NOT EXISTS idx_battle_participants_player ON battle_participants (player_tag);
+		CREATE INDEX IF NOT EXISTS idx_battle_participants_deck ON battle_participants (deck_hash);
+		CREATE INDEX IF NOT EXISTS idx_battle_decks_card ON battle_decks (card_id, battle_id);
+	`)
+	return err
+}
+
+// backfillDeckHashes computes deck_hash for every participant that has a deck but no hash yet.
+func backfillDeckHashes(tx *sql.Tx, d *dialect) error {
+	rows, err := tx.Query(`
+		SELECT bp.battle_id, bp.player_tag, bd.card_id
+		FROM battle_participants bp
+		JOIN battle_decks bd ON bd.battle_id = bp.battle_id AND bd.player_tag = bp.player_tag
+		WHERE bp.deck_hash = '' AND bd.support = ` + d.falseLiteral() + `
+		ORDER BY bp.battle_id, bp.player_tag
+	`)
+	if err != nil {
+		return err
+	}
+
+	decks := make(map[deckKey][]types.Card)
+	for rows.Next() {
+		var key deckKey
+		var c types.Card
+		if err := rows.Scan(&key.battleID, &key.playerTag, &c.ID); err != nil {
+			rows.Close()
+			return err
+		}
+		decks[key] = append(decks[key], c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(d.rebind("UPDATE battle_participants SET deck_hash = ? WHERE battle_id = ? AND player_tag = ?"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for key, cards := range decks {
+		if _, err := stmt.Exec(types.DeckHash(cards), key.battleID, key.playerTag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasColumn reports whether table exists and has the named column.
+func hasColumn(tx *sql.Tx, table, column string) (bool, error) {
+	var n int
+	err := tx.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,189 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import "database/sql"
+
+// postgresMigrations lists every PostgreSQL schema change in the order it must be applied.
+// PostgreSQL support started from the schema SQLite had reached by then, so its history
+// is shorter and its version numbers do not match sqliteMigrations.
+var postgresMigrations = []Migration{
+	{Version: 1, Name: "create battle tables", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS arenas (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS gamemodes (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS players (
+			tag TEXT PRIMARY KEY,
+			name TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS cards (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			maxLevel INTEGER NOT NULL,
+			rarity TEXT NOT NULL,
+			elixirCost INTEGER NOT NULL,
+			max_evolution_level INTEGER NOT NULL DEFAULT 0,
+			icon_url TEXT NOT NULL DEFAULT '',
+			evolution_icon_url TEXT NOT NULL DEFAULT ''
+		);
+		CREATE TABLE IF NOT EXISTS battles (
+			id TEXT PRIMARY KEY,
+			battleTime TEXT NOT NULL,
+			type TEXT NOT NULL,
+			arena_id INTEGER NOT NULL REFERENCES arenas(id),
+			gamemode_id INTEGER NOT NULL REFERENCES gamemodes(id)
+		);
+		CREATE INDEX IF NOT EXISTS idx_battles_battleTime ON battles (battleTime);
+		CREATE TABLE IF NOT EXISTS battle_participants (
+			battle_id TEXT NOT NULL REFERENCES battles(id) ON DELETE CASCADE,
+			player_tag TEXT NOT NULL REFERENCES players(tag),
+			role TEXT NOT NULL,
+			crowns INTEGER NOT NULL,
+			startingTrophies INTEGER NOT NULL,
+			trophyChange INTEGER NOT NULL,
+			elixirLeaked DOUBLE PRECISION NOT NULL,
+			seq BIGSERIAL,
+			PRIMARY KEY (battle_id, player_tag)
+		);
+		CREATE INDEX IF NOT EXISTS idx_battle_participants_player ON battle_participants (player_tag);
+		CREATE TABLE IF NOT EXISTS battle_decks (
+			battle_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL,
+			support BOOLEAN NOT NULL DEFAULT FALSE,
+			slot INTEGER NOT NULL,
+			card_id INTEGER NOT NULL REFERENCES cards(id),
+			card_level INTEGER NOT NULL,
+			evolution_level INTEGER NOT NULL DEFAULT 0,
+			star_level INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (battle_id, player_tag, support, slot),
+			FOREIGN KEY (battle_id, player_tag) REFERENCES battle_participants(battle_id, player_tag) ON DELETE CASCADE
+		);
+	`)},
+	{Version: 2, Name: "create clan tables", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS clans (
+			tag TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			description TEXT NOT NULL,
+			badge_id INTEGER NOT NULL,
+			clan_score INTEGER NOT NULL,
+			clan_war_trophies INTEGER NOT NULL,
+			required_trophies INTEGER NOT NULL,
+			members INTEGER NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS clan_members (
+			clan_tag TEXT NOT NULL REFERENCES clans(tag) ON DELETE CASCADE,
+			player_tag TEXT NOT NULL REFERENCES players(tag),
+			role TEXT NOT NULL,
+			exp_level INTEGER NOT NULL,
+			trophies INTEGER NOT NULL,
+			clan_rank INTEGER NOT NULL,
+			donations INTEGER NOT NULL,
+			donations_received INTEGER NOT NULL,
+			last_seen TEXT NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (clan_tag, player_tag)
+		);
+		CREATE TABLE IF NOT EXISTS river_race_participants (
+			clan_tag TEXT NOT NULL,
+			race_id TEXT NOT NULL,
+			player_tag TEXT NOT NULL REFERENCES players(tag),
+			fame INTEGER NOT NULL,
+			repair_points INTEGER NOT NULL,
+			boat_attacks INTEGER NOT NULL,
+			decks_used INTEGER NOT NULL,
+			decks_used_today INTEGER NOT NULL,
+			updated_at TEXT NOT NULL,
+			PRIMARY KEY (clan_tag, race_id, player_tag)
+		);
+	`)},
+	{Version: 3, Name: "create card catalog", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS card_catalog (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			rarity TEXT NOT NULL,
+			elixir_cost INTEGER NOT NULL,
+			max_level INTEGER NOT NULL,
+			max_evolution_level INTEGER NOT NULL,
+			icon_url TEXT NOT NULL,
+			evolution_icon_url TEXT NOT NULL,
+			updated_at TEXT NOT NULL
+		);
+	`)},
+	{Version: 4, Name: "create raw battle archive", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS raw_battles (
+			battle_id TEXT PRIMARY KEY,
+			fetched_at TEXT NOT NULL,
+			payload BYTEA NOT NULL
+		);
+	`)},
+	{Version: 5, Name: "index battle queries", Up: func(tx *sql.Tx) error {
+		return indexBattleQueries(tx, postgresDialect)
+	}},
+	{Version: 6, Name: "create player snapshots", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS player_snapshots (
+			player_tag TEXT NOT NULL,
+			taken_at TEXT NOT NULL,
+			name TEXT NOT NULL,
+			exp_level INTEGER NOT NULL,
+			exp_points INTEGER NOT NULL,
+			trophies INTEGER NOT NULL,
+			best_trophies INTEGER NOT NULL,
+			wins INTEGER NOT NULL,
+			losses INTEGER NOT NULL,
+			battle_count INTEGER NOT NULL,
+			three_crown_wins INTEGER NOT NULL,
+			star_points INTEGER NOT NULL,
+			arena_id INTEGER NOT NULL,
+			clan_tag TEXT NOT NULL,
+			PRIMARY KEY (player_tag, taken_at),
+			FOREIGN KEY (player_tag) REFERENCES players(tag),
+			FOREIGN KEY (arena_id) REFERENCES arenas(id)
+		);
+		CREATE TABLE IF NOT EXISTS player_snapshot_cards (
+			player_tag TEXT NOT NULL,
+			taken_at TEXT NOT NULL,
+			support BOOLEAN NOT NULL,
+			card_id INTEGER NOT NULL,
+			level INTEGER NOT NULL,
+			star_level INTEGER NOT NULL,
+			evolution_level INTEGER NOT NULL,
+			count INTEGER NOT NULL,
+			PRIMARY KEY (player_tag, taken_at, support, card_id),
+			FOREIGN KEY (player_tag, taken_at) REFERENCES player_snapshots(player_tag, taken_at) ON DELETE CASCADE,
+			FOREIGN KEY (card_id) REFERENCES cards(id)
+		);
+	`)},
+	{Version: 7, Name: "create ingestion gaps", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS ingestion_gaps (
+			player_tag TEXT NOT NULL,
+			after_battle TEXT NOT NULL,
+			before_battle TEXT NOT NULL,
+			detected_at TEXT NOT NULL,
+			PRIMARY KEY (player_tag, after_battle, before_battle)
+		);
+	`)},
+	{Version: 8, Name: "create ingestion runs", Up: execStep(`
+		CREATE TABLE IF NOT EXISTS ingestion_runs (
+			id BIGSERIAL PRIMARY KEY,
+			player_tag TEXT NOT NULL,
+			started_at TEXT NOT NULL,
+			finished_at TEXT NOT NULL DEFAULT '',
+			api_status INTEGER NOT NULL DEFAULT 0,
+			fetched INTEGER NOT NULL DEFAULT 0,
+			inserted INTEGER NOT NULL DEFAULT 0,
+			duplicates INTEGER NOT NULL DEFAULT 0,
+			skipped INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_ingestion_runs_player ON ingestion_runs(player_tag, started_at);
+	`)},
+	{Version: 9, Name: "record the newest battle seen by each ingestion run", Up: execStep(`
+		ALTER TABLE ingestion_runs ADD COLUMN IF NOT EXISTS latest_battle TEXT NOT NULL DEFAULT '';
+	`)},
+}
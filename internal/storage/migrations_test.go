@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// TestMigrateFromV0 upgrades a database created by the original Init, before
+// schema_migrations existed, and checks its battle survives every step.
+func TestMigrateFromV0(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "v0.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "v0.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatalf("loading v0 schema: %v", err)
+	}
+
+	s := NewStorage(db)
+
+	status, err := s.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, m := range status {
+		if m.Applied {
+			t.Errorf("migration %d reported applied on a v0 database", m.Version)
+		}
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Error("MigrationStatus created schema_migrations")
+	}
+
+	ran, err := s.Migrate()
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(ran) != len(sqliteMigrations) {
+		t.Errorf("applied %d migrations, want %d", len(ran), len(sqliteMigrations))
+	}
+
+	status, err = s.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	for _, m := range status {
+		if !m.Applied {
+			t.Errorf("migration %d (%s) not applied", m.Version, m.Name)
+		}
+	}
+
+	battles, err := s.GetBattlesForPlayer("#2PP", AllBattles)
+	if err != nil {
+		t.Fatalf("GetBattlesForPlayer: %v", err)
+	}
+	if len(battles) != 1 {
+		t.Fatalf("found %d battles after migrating, want 1", len(battles))
+	}
+	b := battles[0]
+	if b.BattleTime != "20251012T190501.000Z" || b.GameMode.ID != LadderGameModeID {
+		t.Errorf("migrated battle = %s in mode %d", b.BattleTime, b.GameMode.ID)
+	}
+	if len(b.Team) != 1 || b.Team[0].Crowns != 3 || len(b.Team[0].Cards) != 2 {
+		t.Errorf("migrated team = %+v", b.Team)
+	}
+	if len(b.Opponent) != 1 || b.Opponent[0].Tag != "#8QQ" || len(b.Opponent[0].Cards) != 1 {
+		t.Errorf("migrated opponent = %+v", b.Opponent)
+	}
+	var id string
+	if err := db.QueryRow("SELECT id FROM battles").Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if want := types.BattleID(b.BattleTime, []string{"#2PP", "#8QQ"}); id != want {
+		t.Errorf("stored battle ID = %s, want %s", id, want)
+	}
+
+	// Migrating again is a no-op.
+	if ran, err := s.Migrate(); err != nil || len(ran) != 0 {
+		t.Errorf("second Migrate ran %d migrations, err %v", len(ran), err)
+	}
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+const (
+	// postgresDSNEnv names the environment variable holding the URL of an existing
+	// PostgreSQL server to test against instead of the embedded one.
+	postgresDSNEnv = "LOGGOB_TEST_POSTGRES_DSN"
+
+	// postgresOffEnv, set to "off", skips the PostgreSQL tests explicitly, e.g.
+	// where the embedded server's binaries cannot be downloaded.
+	postgresOffEnv = "LOGGOB_TEST_POSTGRES"
+)
+
+// embedded is the PostgreSQL server started by the first test that needs one
+// and stopped by TestMain once every test has run.
+var embedded struct {
+	once sync.Once
+	dsn  string
+	err  error
+	db   *embeddedpostgres.EmbeddedPostgres
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if embedded.db != nil {
+		if err := embedded.db.Stop(); err != nil {
+			fmt.Fprintln(os.Stderr, "stopping embedded PostgreSQL:", err)
+		}
+	}
+	os.Exit(code)
+}
+
+// startEmbeddedPostgres downloads PostgreSQL binaries on first use (they are
+// cached in ~/.embedded-postgres-go) and starts a throwaway server on a free port.
+func startEmbeddedPostgres() (string, error) {
+	if os.Geteuid() == 0 {
+		return "", fmt.Errorf("PostgreSQL refuses to run as root; run the tests as another user or set %s", postgresDSNEnv)
+	}
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	port := uint32(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+
+	dir, err := os.MkdirTemp("", "loggob-postgres-")
+	if err != nil {
+		return "", err
+	}
+
+	var logs bytes.Buffer
+	cfg := embeddedpostgres.DefaultConfig().
+		Port(port).
+		Database("loggob").
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		StartTimeout(time.Minute).
+		Logger(&logs)
+
+	embedded.db = embeddedpostgres.NewDatabase(cfg)
+	if err := embedded.db.Start(); err != nil {
+		embedded.db = nil
+		return "", fmt.Errorf("%w\n%s", err, logs.String())
+	}
+	return cfg.GetConnectionURL() + "?sslmode=disable", nil
+}
+
+// postgresTestDSN returns the server the PostgreSQL tests use: the one named by
+// postgresDSNEnv, or else the embedded server. A server that cannot be started
+// fails the test rather than skipping it, so the PostgreSQL backend is never
+// silently left untested.
+func postgresTestDSN(t *testing.T) string {
+	t.Helper()
+
+	if dsn := os.Getenv(postgresDSNEnv); dsn != "" {
+		return dsn
+	}
+	if os.Getenv(postgresOffEnv) == "off" {
+		t.Skipf("PostgreSQL tests disabled by %s=off", postgresOffEnv)
+	}
+
+	embedded.once.Do(func() {
+		embedded.dsn, embedded.err = startEmbeddedPostgres()
+	})
+	if embedded.err != nil {
+		t.Fatalf("starting embedded PostgreSQL (set %s to use an existing server): %v", postgresDSNEnv, embedded.err)
+	}
+	return embedded.dsn
+}
+
+// newPostgresTestStorage returns a migrated PostgreSQL database in a schema of
+// its own, which is dropped when the test ends.
+func newPostgresTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	dsn := postgresTestDSN(t)
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("loggob_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatalf("creating test schema: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	s, err := Open(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		admin.Close()
+	})
+
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return s
+}
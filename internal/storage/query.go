@@ -0,0 +1,251 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// Result selects battles by their outcome for the queried player.
+type Result string
+
+// Battle outcomes. AnyResult matches every battle.
+const (
+	AnyResult Result = ""
+	Win       Result = "win"
+	Loss      Result = "loss"
+	Draw      Result = "draw"
+)
+
+// ParseResult parses "win", "loss" or "draw", in any case. An empty string yields AnyResult.
+func ParseResult(s string) (Result, error) {
+	switch r := Result(strings.ToLower(strings.TrimSpace(s))); r {
+	case AnyResult, Win, Loss, Draw:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid result %q (want win, loss or draw)", s)
+}
+
+// ErrNoPlayer is returned for a BattleQuery without a PlayerTag.
+var ErrNoPlayer = errors.New("battle query needs a player tag")
+
+// BattleQuery selects a player's battles. PlayerTag is required; every other
+// field narrows the selection and matches everything when left at its zero value.
+// Player-relative fields (Result, trophies, cards, deck) refer to PlayerTag's side.
+type BattleQuery struct {
+	PlayerTag   string       // The player whose battles to select
+	OpponentTag string       // Only battles against this player
+	Filter      BattleFilter // Battle types and game modes
+
+	Since time.Time // Only battles at or after this time
+	Until time.Time // Only battles before this time
+
+	ArenaIDs []int32 // Only battles in these arenas
+	Result   Result  // Only battles with this outcome
+
+	MinTrophies int32 // Only battles the player started with at least this many trophies
+	MaxTrophies int32 // Only battles the player started with at most this many trophies
+
+	Cards         []int32 // The player's deck or tower troop contains every one of these cards
+	OpponentCards []int32 // The opponent's deck or tower troop contains every one of these cards
+	DeckHash      string  // The player's deck hashes to this value; see types.DeckHash
+}
+
+// where renders the query as a FROM/WHERE clause selecting battles b, with the
+// player's participant row aliased as me, along with its arguments.
+func (q BattleQuery) where() (string, []interface{}, error) {
+	if q.PlayerTag == "" {
+		return "", nil, ErrNoPlayer
+	}
+
+	var sb strings.Builder
+	args := []interface{}{q.PlayerTag}
+
+	sb.WriteString(`
+		FROM battles b
+		JOIN battle_participants me ON me.battle_id = b.id
+		WHERE me.player_tag = ?`)
+
+	cond, condArgs := q.Filter.where("b")
+	sb.WriteString(cond)
+	args = append(args, condArgs...)
+
+	if q.OpponentTag != "" {
+		sb.WriteString(`
+		AND EXISTS (
+			SELECT 1 FROM battle_participants o
+			WHERE o.battle_id = b.id AND o.role <> me.role AND o.player_tag = ?
+		)`)
+		args = append(args, q.OpponentTag)
+	}
+
+	if !q.Since.IsZero() {
+		sb.WriteString(" AND b.battleTime >= ?")
+		args = append(args, q.Since.UTC().Format(types.BattleTimeLayout))
+	}
+	if !q.Until.IsZero() {
+		sb.WriteString(" AND b.battleTime < ?")
+		args = append(args, q.Until.UTC().Format(types.BattleTimeLayout))
+	}
+
+	if len(q.ArenaIDs) > 0 {
+		sb.WriteString(" AND b.arena_id IN (" + placeholders(len(q.ArenaIDs)) + ")")
+		for _, id := range q.ArenaIDs {
+			args = append(args, id)
+		}
+	}
+
+	if q.Result != AnyResult {
+		op := map[Result]string{Win: ">", Loss: "<", Draw: "="}[q.Result]
+		if op == "" {
+			return "", nil, fmt.Errorf("invalid result %q", q.Result)
+		}
+		sb.WriteString(`
+		AND me.crowns ` + op + ` (
+			SELECT MAX(o.crowns) FROM battle_participants o
+			WHERE o.battle_id = b.id AND o.role <> me.role
+		)`)
+	}
+
+	if q.MinTrophies > 0 {
+		sb.WriteString(" AND me.startingTrophies >= ?")
+		args = append(args, q.MinTrophies)
+	}
+	if q.MaxTrophies > 0 {
+		sb.WriteString(" AND me.startingTrophies <= ?")
+		args = append(args, q.MaxTrophies)
+	}
+
+	for _, id := range q.Cards {
+		sb.WriteString(`
+		AND EXISTS (
+			SELECT 1 FROM battle_decks d
+			WHERE d.battle_id = b.id AND d.player_tag = me.player_tag AND d.card_id = ?
+		)`)
+		args = append(args, id)
+	}
+	for _, id := range q.OpponentCards {
+		sb.WriteString(`
+		AND EXISTS (
+			SELECT 1 FROM battle_decks d
+			JOIN battle_participants o ON o.battle_id = d.battle_id AND o.player_tag = d.player_tag
+			WHERE d.battle_id = b.id AND o.role <> me.role AND d.card_id = ?
+		)`)
+		args = append(args, id)
+	}
+
+	if q.DeckHash != "" {
+		sb.WriteString(" AND me.deck_hash = ?")
+		args = append(args, q.DeckHash)
+	}
+
+	return sb.String(), args, nil
+}
+
+// idQuery builds a query selecting the IDs of the battles on a page.
+// The loaders use it as a subquery so each of them runs as a single statement.
+func (q BattleQuery) idQuery(page Page) (string, []interface{}, error) {
+	from, args, err := q.where()
+	if err != nil {
+		return "", nil, err
+	}
+	query := "SELECT b.id" + from
+
+	if page.Before != "" {
+		battleTime, id, err := decodeCursor(page.Before)
+		if err != nil {
+			return "", nil, err
+		}
+		query += " AND (b.battleTime < ? OR (b.battleTime = ? AND b.id < ?))"
+		args = append(args, battleTime, battleTime, id)
+	}
+
+	// Order only matters for choosing which battles fall on the page
+	if page.Limit > 0 {
+		query += " ORDER BY b.battleTime DESC, b.id DESC LIMIT ?"
+		args = append(args, page.Limit)
+	}
+
+	return query, args, nil
+}
+
+// QueryBattles retrieves one page of the battles matching q, most recent first,
+// along with the cursor for the next page. The cursor is empty when there are no older battles.
+// Each battle is seen from q.PlayerTag's side: the player is always on Team, even in battles
+// stored from the opponent's battle log.
+//
+// Battles, participants and decks are loaded with three queries however many battles the page holds.
+func (s *Storage) QueryBattles(q BattleQuery, page Page) ([]types.Battle, string, error) {
+	idQuery, idArgs, err := q.idQuery(page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	battles, ids, err := s.loadBattles(idQuery, idArgs)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(battles) == 0 {
+		return nil, "", nil
+	}
+
+	decks, err := s.loadDecks(idQuery, idArgs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.loadParticipants(idQuery, idArgs, battles, ids, decks); err != nil {
+		return nil, "", err
+	}
+	for i := range battles {
+		orient(&battles[i], q.PlayerTag)
+	}
+
+	next := ""
+	if page.Limit > 0 && len(battles) == page.Limit {
+		last := len(battles) - 1
+		next = encodeCursor(battles[last].BattleTime, ids[last])
+	}
+
+	return battles, next, nil
+}
+
+// orient swaps the sides of b if tag is an opponent, so tag's side is Team.
+// A battle between two tracked players is stored once, from whichever side was fetched first.
+func orient(b *types.Battle, tag string) {
+	for _, p := range b.Opponent {
+		if p.Tag == tag {
+			b.Team, b.Opponent = b.Opponent, b.Team
+			return
+		}
+	}
+}
+
+// CountBattles returns how many battles match q without loading them.
+func (s *Storage) CountBattles(q BattleQuery) (int, error) {
+	from, args, err := q.where()
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = s.DB.QueryRow(s.rebind("SELECT COUNT(*)"+from), args...).Scan(&n)
+	return n, err
+}
+
+// FindCard looks up a card ID by name, case-insensitively, in the card catalog
+// and the cards seen in battle logs. It returns sql.ErrNoRows for unknown cards.
+func (s *Storage) FindCard(name string) (int32, error) {
+	var id int32
+	err := s.DB.QueryRow(s.rebind(`
+		SELECT id FROM card_catalog WHERE LOWER(name) = LOWER(?)
+		UNION
+		SELECT id FROM cards WHERE LOWER(name) = LOWER(?)
+		LIMIT 1
+	`), name, name).Scan(&id)
+	return id, err
+}
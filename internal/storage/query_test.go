@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// TestQueryBattlesOrientsSides checks that a battle between two tracked players,
+// stored once, is seen from the queried player's side.
+func TestQueryBattlesOrientsSides(t *testing.T) {
+	s := newTestStorage(t)
+
+	b := testBattle("20251012T190501.000Z", "#2PP", "#8QQ", 3, 1)
+	if _, err := s.InsertBattles(context.Background(), []types.Battle{b}); err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+
+	for _, tc := range []struct {
+		tag, opponent string
+		result        Result
+		crowns        int32
+	}{
+		{"#2PP", "#8QQ", Win, 3},
+		{"#8QQ", "#2PP", Loss, 1},
+	} {
+		battles, _, err := s.QueryBattles(BattleQuery{PlayerTag: tc.tag, Result: tc.result}, Page{})
+		if err != nil {
+			t.Fatalf("QueryBattles(%s): %v", tc.tag, err)
+		}
+		if len(battles) != 1 {
+			t.Fatalf("QueryBattles(%s) returned %d battles, want 1", tc.tag, len(battles))
+		}
+		got := battles[0]
+		if got.Team[0].Tag != tc.tag || got.Opponent[0].Tag != tc.opponent {
+			t.Errorf("QueryBattles(%s): team %s, opponent %s", tc.tag, got.Team[0].Tag, got.Opponent[0].Tag)
+		}
+		if got.Team[0].Crowns != tc.crowns {
+			t.Errorf("QueryBattles(%s): team crowns %d, want %d", tc.tag, got.Team[0].Crowns, tc.crowns)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"time"
+)
+
+// IngestionRun is the audit record of one fetch of a player's battle log.
+// A run is recorded when it starts, so one with no FinishedAt was interrupted.
+type IngestionRun struct {
+	ID         int64
+	PlayerTag  string
+	StartedAt  time.Time
+	FinishedAt time.Time // Zero while the run is in progress or if it never finished
+
+	// APIStatus is the HTTP status of the battle log request. It is 0 if no response
+	// was received, including when a fresh cached log was used without asking the API.
+	APIStatus int
+
+	Fetched    int // Battles in the fetched log
+	Inserted   int // New battles stored
+	Duplicates int // Battles already stored
+	Skipped    int // Battles left out by the ingestion filter
+
+	// LatestBattle is the BattleTime of the newest battle in the fetched log, stored or not.
+	// It is only set for runs that saved their battles.
+	LatestBattle string
+
+	Error string // Why the run failed; empty on success
+}
+
+// Failed reports whether the run ended in an error or never finished.
+func (r IngestionRun) Failed() bool {
+	return r.Error != "" || r.FinishedAt.IsZero()
+}
+
+// Cached reports whether the run succeeded using a cached battle log, without an API request.
+func (r IngestionRun) Cached() bool {
+	return r.APIStatus == 0 && !r.Failed()
+}
+
+// RunFilter selects ingestion runs to list.
+type RunFilter struct {
+	PlayerTag string // Empty matches every player
+	Failed    bool   // Only runs that failed or never finished
+	Limit     int    // Zero means no limit
+}
+
+// StartRun records that a fetch of tag's battle log has started and returns the run's ID.
+func (s *Storage) StartRun(tag string, startedAt time.Time) (int64, error) {
+	var id int64
+	err := s.DB.QueryRow(s.rebind(`
+		INSERT INTO ingestion_runs (player_tag, started_at) VALUES (?, ?)
+		RETURNING id
+	`), tag, formatRunTime(startedAt)).Scan(&id)
+	return id, err
+}
+
+// FinishRun records the outcome of the run with r.ID.
+func (s *Storage) FinishRun(r IngestionRun) error {
+	_, err := s.DB.Exec(s.rebind(`
+		UPDATE ingestion_runs
+		SET finished_at = ?, api_status = ?, fetched = ?, inserted = ?, duplicates = ?, skipped = ?,
+			latest_battle = ?, error = ?
+		WHERE id = ?
+	`), formatRunTime(r.FinishedAt), r.APIStatus, r.Fetched, r.Inserted, r.Duplicates, r.Skipped,
+		r.LatestBattle, r.Error, r.ID)
+	return err
+}
+
+// LatestFetchedBattle returns the BattleTime of the newest battle any run has seen
+// in tag's battle log, or "" if no run has. Unlike NewestBattleTime it counts
+// battles left out by the ingestion filter.
+func (s *Storage) LatestFetchedBattle(tag string) (string, error) {
+	var latest string
+	err := s.DB.QueryRow(s.rebind(`
+		SELECT COALESCE(MAX(latest_battle), '') FROM ingestion_runs WHERE player_tag = ?
+	`), tag).Scan(&latest)
+	return latest, err
+}
+
+// IngestionRuns returns the runs matching f, most recent first.
+func (s *Storage) IngestionRuns(f RunFilter) ([]IngestionRun, error) {
+	q := `
+		SELECT id, player_tag, started_at, finished_at, api_status, fetched, inserted, duplicates, skipped,
+			latest_battle, error
+		FROM ingestion_runs
+		WHERE (? = '' OR player_tag = ?)
+	`
+	args := []any{f.PlayerTag, f.PlayerTag}
+	if f.Failed {
+		q += " AND (error <> '' OR finished_at = '')"
+	}
+	q += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	rows, err := s.DB.Query(s.rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []IngestionRun
+
+	for rows.Next() {
+		var r IngestionRun
+		var startedAt, finishedAt string
+		if err := rows.Scan(&r.ID, &r.PlayerTag, &startedAt, &finishedAt, &r.APIStatus,
+			&r.Fetched, &r.Inserted, &r.Duplicates, &r.Skipped, &r.LatestBattle, &r.Error); err != nil {
+			return nil, err
+		}
+		r.StartedAt, _ = time.Parse(time.RFC3339Nano, startedAt)
+		if finishedAt != "" {
+			r.FinishedAt, _ = time.Parse(time.RFC3339Nano, finishedAt)
+		}
+		runs = append(runs, r)
+	}
+
+	return runs, rows.Err()
+}
+
+// formatRunTime formats run timestamps so they sort chronologically as text.
+func formatRunTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z07:00")
+}
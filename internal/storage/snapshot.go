@@ -0,0 +1,233 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// PlayerSnapshot is a player's profile as it was at one point in time.
+// Only the fields stored in player_snapshots are filled in on Profile: tag, name,
+// levels, trophies, win/loss counts, star points, arena ID, clan tag and cards.
+type PlayerSnapshot struct {
+	TakenAt time.Time
+	Profile types.PlayerProfile
+}
+
+// SavePlayerSnapshot records p, fetched from /v1/players/{tag}, as the player's profile at takenAt.
+// Saving a second snapshot with the same time replaces the first.
+func (s *Storage) SavePlayerSnapshot(p *types.PlayerProfile, takenAt time.Time) error {
+	at := takenAt.UTC().Format(time.RFC3339)
+
+	clanTag := ""
+	if p.Clan != nil {
+		clanTag = p.Clan.Tag
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO players (tag, name) VALUES (?, ?) ON CONFLICT DO NOTHING", []interface{}{p.Tag, p.Name}},
+		{"INSERT INTO arenas (id, name) VALUES (?, ?) ON CONFLICT DO NOTHING", []interface{}{p.Arena.ID, p.Arena.Name}},
+		{"DELETE FROM player_snapshot_cards WHERE player_tag = ? AND taken_at = ?", []interface{}{p.Tag, at}},
+		{"DELETE FROM player_snapshots WHERE player_tag = ? AND taken_at = ?", []interface{}{p.Tag, at}},
+		{`INSERT INTO player_snapshots
+		  (player_tag, taken_at, name, exp_level, exp_points, trophies, best_trophies, wins, losses,
+		   battle_count, three_crown_wins, star_points, arena_id, clan_tag)
+		  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, []interface{}{
+			p.Tag, at, p.Name, p.ExpLevel, p.ExpPoints, p.Trophies, p.BestTrophies, p.Wins, p.Losses,
+			p.BattleCount, p.ThreeCrownWins, p.StarPoints, p.Arena.ID, clanTag,
+		}},
+	}
+	for _, st := range statements {
+		if _, err := tx.Exec(s.rebind(st.query), st.args...); err != nil {
+			return err
+		}
+	}
+
+	card, err := tx.Prepare(s.rebind(upsertCardSQL))
+	if err != nil {
+		return err
+	}
+	defer card.Close()
+
+	owned, err := tx.Prepare(s.rebind(`INSERT INTO player_snapshot_cards
+		(player_tag, taken_at, support, card_id, level, star_level, evolution_level, count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`))
+	if err != nil {
+		return err
+	}
+	defer owned.Close()
+
+	for _, collection := range []struct {
+		support bool
+		cards   []types.Card
+	}{{false, p.Cards}, {true, p.SupportCards}} {
+		for _, c := range collection.cards {
+			_, err := card.Exec(
+				c.ID, c.Name, c.MaxLevel, c.Rarity, c.ElixirCost,
+				c.MaxEvolutionLevel, c.IconURLs.Medium, c.IconURLs.EvolutionMedium,
+			)
+			if err != nil {
+				return err
+			}
+
+			_, err = owned.Exec(p.Tag, at, collection.support, c.ID, c.Level, c.StarLevel, c.EvolutionLevel, c.Count)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LatestPlayerSnapshot returns the player's most recent snapshot, including their
+// card collection. It returns sql.ErrNoRows when no snapshot has been taken.
+func (s *Storage) LatestPlayerSnapshot(tag string) (*PlayerSnapshot, error) {
+	snapshots, err := s.loadSnapshots(tag, time.Time{}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	snap := &snapshots[0]
+	if err := s.loadSnapshotCards(snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// PlayerSnapshots returns the player's snapshots taken at or after since, oldest first,
+// without their card collections. Use it to chart progression over time.
+func (s *Storage) PlayerSnapshots(tag string, since time.Time) ([]PlayerSnapshot, error) {
+	snapshots, err := s.loadSnapshots(tag, since, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// loadSnapshots returns newest first
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+	return snapshots, nil
+}
+
+// loadSnapshots returns up to limit of the player's snapshots taken at or after since,
+// newest first. A zero limit returns every snapshot.
+func (s *Storage) loadSnapshots(tag string, since time.Time, limit int) ([]PlayerSnapshot, error) {
+	query := `
+		SELECT ps.taken_at, ps.player_tag, ps.name, ps.exp_level, ps.exp_points, ps.trophies, ps.best_trophies,
+		       ps.wins, ps.losses, ps.battle_count, ps.three_crown_wins, ps.star_points,
+		       ps.arena_id, COALESCE(a.name, ''), ps.clan_tag
+		FROM player_snapshots ps
+		LEFT JOIN arenas a ON a.id = ps.arena_id
+		WHERE ps.player_tag = ? AND ps.taken_at >= ?
+		ORDER BY ps.taken_at DESC`
+	args := []interface{}{tag, ""}
+	if !since.IsZero() {
+		args[1] = since.UTC().Format(time.RFC3339)
+	}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.DB.Query(s.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []PlayerSnapshot
+
+	for rows.Next() {
+		var snap PlayerSnapshot
+		var takenAt, clanTag string
+		p := &snap.Profile
+		err := rows.Scan(
+			&takenAt,
+			&p.Tag,
+			&p.Name,
+			&p.ExpLevel,
+			&p.ExpPoints,
+			&p.Trophies,
+			&p.BestTrophies,
+			&p.Wins,
+			&p.Losses,
+			&p.BattleCount,
+			&p.ThreeCrownWins,
+			&p.StarPoints,
+			&p.Arena.ID,
+			&p.Arena.Name,
+			&clanTag,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		snap.TakenAt, _ = time.Parse(time.RFC3339, takenAt)
+		if clanTag != "" {
+			p.Clan = &types.ClanRef{Tag: clanTag}
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// loadSnapshotCards fills in the card collection of snap.
+func (s *Storage) loadSnapshotCards(snap *PlayerSnapshot) error {
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT psc.support, c.id, COALESCE(cc.name, c.name), COALESCE(cc.rarity, c.rarity),
+		       COALESCE(cc.max_level, c.maxLevel), COALESCE(cc.elixir_cost, c.elixirCost),
+		       psc.level, psc.star_level, psc.evolution_level, psc.count
+		FROM player_snapshot_cards psc
+		JOIN cards c ON c.id = psc.card_id
+		LEFT JOIN card_catalog cc ON cc.id = psc.card_id
+		WHERE psc.player_tag = ? AND psc.taken_at = ?
+		ORDER BY psc.support, c.id
+	`), snap.Profile.Tag, snap.TakenAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c types.Card
+		var support bool
+		err := rows.Scan(
+			&support,
+			&c.ID,
+			&c.Name,
+			&c.Rarity,
+			&c.MaxLevel,
+			&c.ElixirCost,
+			&c.Level,
+			&c.StarLevel,
+			&c.EvolutionLevel,
+			&c.Count,
+		)
+		if err != nil {
+			return err
+		}
+		if support {
+			snap.Profile.SupportCards = append(snap.Profile.SupportCards, c)
+		} else {
+			snap.Profile.Cards = append(snap.Profile.Cards, c)
+		}
+	}
+
+	return rows.Err()
+}
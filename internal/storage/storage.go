@@ -2,206 +2,134 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/elliot727/log-gob/internal/types"
 )
 
 // Storage represents a database storage handler with a SQL database connection.
+// It implements Store for both SQLite and PostgreSQL.
 type Storage struct {
 	DB *sql.DB
+
+	// Ingest decides which battles InsertBattle stores. The default stores every battle.
+	Ingest BattleFilter
+
+	dialect *dialect // nil means SQLite
 }
 
-// NewStorage creates a new storage instance with the provided database connection.
+// NewStorage creates a new storage instance with the provided SQLite database connection.
 func NewStorage(db *sql.DB) *Storage {
 	return &Storage{
-		DB: db,
+		DB:      db,
+		dialect: sqliteDialect,
 	}
 }
 
-// Init creates the required database tables if they don't exist.
-// This includes tables for arenas, game modes, players, cards, battles, participants, and decks.
-func (s *Storage) Init() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS arenas (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL
-	);
-	CREATE TABLE IF NOT EXISTS gamemodes (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL
-	);
-	CREATE TABLE IF NOT EXISTS players (
-		tag TEXT PRIMARY KEY,
-		name TEXT NOT NULL
-	);
-	CREATE TABLE IF NOT EXISTS cards (
-		id INTEGER PRIMARY KEY,
-		name TEXT NOT NULL,
-		maxLevel INTEGER NOT NULL,
-		rarity TEXT NOT NULL,
-		elixirCost INTEGER NOT NULL
-	);
-	CREATE TABLE IF NOT EXISTS battles (
-		battleTime TEXT PRIMARY KEY,
-		type TEXT NOT NULL,
-		arena_id INTEGER NOT NULL,
-		gamemode_id INTEGER NOT NULL,
-		FOREIGN KEY (arena_id) REFERENCES arenas(id),
-		FOREIGN KEY (gamemode_id) REFERENCES gamemodes(id)
-	);
-	CREATE TABLE IF NOT EXISTS battle_participants (
-		battleTime TEXT NOT NULL,
-		player_tag TEXT NOT NULL,
-		role TEXT NOT NULL,
-		crowns INTEGER NOT NULL,
-		startingTrophies INTEGER NOT NULL,
-		trophyChange INTEGER NOT NULL,
-		elixirLeaked REAL NOT NULL,
-		PRIMARY KEY (battleTime, player_tag),
-		FOREIGN KEY (battleTime) REFERENCES battles(battleTime) ON DELETE CASCADE,
-		FOREIGN KEY (player_tag) REFERENCES players(tag)
-	);
-	CREATE TABLE IF NOT EXISTS battle_decks (
-		battleTime TEXT NOT NULL,
-		player_tag TEXT NOT NULL,
-		card_id INTEGER NOT NULL,
-		card_level INTEGER NOT NULL,
-		PRIMARY KEY (battleTime, player_tag, card_id),
-		FOREIGN KEY (battleTime, player_tag) REFERENCES battle_participants(battleTime, player_tag) ON DELETE CASCADE,
-		FOREIGN KEY (card_id) REFERENCES cards(id)
-	);
-	`
-
-	_, err := s.DB.Exec(schema)
-	return err
+// NewPostgresStorage creates a new storage instance with the provided PostgreSQL database connection.
+func NewPostgresStorage(db *sql.DB) *Storage {
+	return &Storage{
+		DB:      db,
+		dialect: postgresDialect,
+	}
 }
 
-// InsertBattle saves a battle and its related data to the database.
-// It only processes PvP battles in the Ladder game mode and ignores other battle types/game modes.
-// The function handles inserting or updating data for arenas, game modes, players, cards,
-// battle records, participants, and decks.
-func (s *Storage) InsertBattle(b *types.Battle) error {
-	if b.BattleType != "PvP" {
-		return nil
+// sqlDialect returns the dialect of the underlying database.
+func (s *Storage) sqlDialect() *dialect {
+	if s.dialect == nil {
+		return sqliteDialect
 	}
+	return s.dialect
+}
 
-	// Only store battles in the Ladder game mode (ID 72000006)
-	if b.GameMode.ID != 72000006 {
-		return nil
-	}
+// rebind rewrites query's placeholders for the underlying database.
+func (s *Storage) rebind(query string) string {
+	return s.sqlDialect().rebind(query)
+}
 
-	_, err := s.DB.Exec(
-		"INSERT OR IGNORE INTO arenas (id, name) VALUES (?, ?)",
-		b.Arena.ID, b.Arena.Name,
-	)
-	if err != nil {
-		return err
-	}
+// Init brings the database schema up to date by applying any pending migrations.
+// The schema covers arenas, game modes, players, cards, battles, participants, and decks,
+// as well as clans, clan members, river race participation and the global card catalog.
+// See Migrate for details.
+func (s *Storage) Init() error {
+	_, err := s.Migrate()
+	return err
+}
 
-	_, err = s.DB.Exec(
-		"INSERT OR IGNORE INTO gamemodes (id, name) VALUES (?, ?)",
-		b.GameMode.ID, b.GameMode.Name,
-	)
+// InsertBattle saves a single battle and its related data to the database.
+// Battles rejected by s.Ingest are not stored and return ErrFiltered; battles
+// that are already stored are left untouched. See InsertBattles.
+func (s *Storage) InsertBattle(b *types.Battle) error {
+	res, err := s.InsertBattles(context.Background(), []types.Battle{*b})
 	if err != nil {
 		return err
 	}
-
-	_, err = s.DB.Exec(
-		"INSERT OR IGNORE INTO battles (battleTime, type, arena_id, gamemode_id) VALUES (?, ?, ?, ?)",
-		b.BattleTime, b.BattleType, b.Arena.ID, b.GameMode.ID,
-	)
-	if err != nil {
-		return err
+	if res.Skipped > 0 {
+		return ErrFiltered
 	}
+	return nil
+}
 
-	allPlayers := append(b.Team, b.Opponent...)
-
-	for _, p := range allPlayers {
-
-		_, err := s.DB.Exec(
-			"INSERT OR IGNORE INTO players (tag, name) VALUES (?, ?)",
-			p.Tag, p.Name,
-		)
-		if err != nil {
-			return err
-		}
+// Page selects a window of a player's battles, newest first.
+type Page struct {
+	Limit  int    // Maximum number of battles to return; 0 means no limit
+	Before string // Cursor from a previous page; only older battles are returned
+}
 
-		for _, c := range p.Cards {
-			_, err = s.DB.Exec(
-				"INSERT OR IGNORE INTO cards (id, name, maxLevel, rarity, elixirCost) VALUES (?, ?, ?, ?, ?)",
-				c.ID, c.Name, c.MaxLevel, c.Rarity, c.ElixirCost,
-			)
-			if err != nil {
-				return err
-			}
-		}
+// GetBattlesForPlayer retrieves every battle for a specific player that matches filter.
+// Pass LadderBattles for Ladder-only stats or AllBattles for every mode.
+// Results are ordered by battle time in descending order (most recent first).
+func (s *Storage) GetBattlesForPlayer(tag string, filter BattleFilter) ([]types.Battle, error) {
+	battles, _, err := s.GetBattlesPage(tag, filter, Page{})
+	return battles, err
+}
 
-		role := "opponent"
-		for _, t := range b.Team {
-			if t.Tag == p.Tag {
-				role = "team"
-				break
-			}
-		}
+// GetBattlesPage retrieves one page of a player's battles matching filter, most recent first,
+// along with the cursor for the next page. See QueryBattles.
+func (s *Storage) GetBattlesPage(tag string, filter BattleFilter, page Page) ([]types.Battle, string, error) {
+	return s.QueryBattles(BattleQuery{PlayerTag: tag, Filter: filter}, page)
+}
 
-		_, err = s.DB.Exec(
-			`INSERT OR REPLACE INTO battle_participants
-			 (battleTime, player_tag, role, crowns, startingTrophies, trophyChange, elixirLeaked)
-			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			b.BattleTime,
-			p.Tag,
-			role,
-			p.Crowns,
-			int64(p.StartingTrophies),
-			int64(p.TrophyChange),
-			p.ElixirLeaked,
-		)
-		if err != nil {
-			return err
-		}
+// encodeCursor builds the opaque cursor that follows the given battle.
+func encodeCursor(battleTime, battleID string) string {
+	return battleTime + "_" + battleID
+}
 
-		for _, c := range p.Cards {
-			_, err := s.DB.Exec(
-				`INSERT OR REPLACE INTO battle_decks
-				 (battleTime, player_tag, card_id, card_level)
-				 VALUES (?, ?, ?, ?)`,
-				b.BattleTime,
-				p.Tag,
-				c.ID,
-				c.Level,
-			)
-			if err != nil {
-				return err
-			}
-		}
+func decodeCursor(cursor string) (battleTime, battleID string, err error) {
+	battleTime, battleID, ok := strings.Cut(cursor, "_")
+	if !ok || battleTime == "" || battleID == "" {
+		return "", "", fmt.Errorf("invalid cursor %q", cursor)
 	}
-
-	return nil
+	return battleTime, battleID, nil
 }
 
-// GetBattlesForPlayer retrieves all battles for a specific player from the database.
-// Results are ordered by battle time in descending order (most recent first).
-func (s *Storage) GetBattlesForPlayer(tag string) ([]types.Battle, error) {
-	rows, err := s.DB.Query(`
-		SELECT b.battleTime, b.type, a.id, a.name, g.id, g.name
+// loadBattles retrieves the battles selected by idQuery without their participants,
+// returning their IDs alongside them.
+func (s *Storage) loadBattles(idQuery string, args []interface{}) ([]types.Battle, []string, error) {
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT b.id, b.battleTime, b.type, a.id, a.name, g.id, g.name
 		FROM battles b
 		JOIN arenas a ON b.arena_id = a.id
 		JOIN gamemodes g ON b.gamemode_id = g.id
-		JOIN battle_participants bp ON bp.battleTime = b.battleTime
-		WHERE bp.player_tag = ?
-		ORDER BY b.battleTime DESC
-	`, tag)
+		WHERE b.id IN (`+idQuery+`)
+		ORDER BY b.battleTime DESC, b.id DESC
+	`), args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var battles []types.Battle
+	var ids []string
 
 	for rows.Next() {
 		var b types.Battle
+		var battleID string
 		err := rows.Scan(
+			&battleID,
 			&b.BattleTime,
 			&b.BattleType,
 			&b.Arena.ID,
@@ -210,47 +138,56 @@ func (s *Storage) GetBattlesForPlayer(tag string) ([]types.Battle, error) {
 			&b.GameMode.Name,
 		)
 		if err != nil {
-			return nil, err
-		}
-
-		team, err := s.loadParticipants(b.BattleTime, "team")
-		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		opponent, err := s.loadParticipants(b.BattleTime, "opponent")
-		if err != nil {
-			return nil, err
-		}
-
-		b.Team = team
-		b.Opponent = opponent
 		battles = append(battles, b)
+		ids = append(ids, battleID)
 	}
 
-	return battles, nil
+	return battles, ids, rows.Err()
+}
+
+// deckKey identifies one player's deck in one battle.
+type deckKey struct {
+	battleID  string
+	playerTag string
 }
 
-// loadParticipants retrieves all participants for a specific battle with the given role (team or opponent).
-func (s *Storage) loadParticipants(battleTime string, role string) ([]types.Player, error) {
-	rows, err := s.DB.Query(`
-		SELECT p.tag, p.name, bp.crowns, bp.startingTrophies, bp.trophyChange, bp.elixirLeaked
+// deck holds one player's cards in one battle, each in slot order.
+type deck struct {
+	cards   []types.Card
+	support []types.Card
+}
+
+// loadParticipants retrieves the participants of every battle selected by idQuery
+// and adds them, with their decks, to the team or opponent side of battles.
+func (s *Storage) loadParticipants(idQuery string, args []interface{}, battles []types.Battle, ids []string, decks map[deckKey]*deck) error {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT bp.battle_id, bp.role, p.tag, p.name, bp.crowns, bp.startingTrophies, bp.trophyChange, bp.elixirLeaked
 		FROM battle_participants bp
 		JOIN players p ON p.tag = bp.player_tag
-		WHERE bp.battleTime = ? AND bp.role = ?
-	`, battleTime, role)
+		WHERE bp.battle_id IN (`+idQuery+`)
+		ORDER BY bp.`+s.sqlDialect().rowOrder+`
+	`), args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var players []types.Player
-
 	for rows.Next() {
 		var p types.Player
+		var battleID, role string
 		var startingTrophies sql.NullInt64
 		var trophyChange sql.NullInt32
 
 		err := rows.Scan(
+			&battleID,
+			&role,
 			&p.Tag,
 			&p.Name,
 			&p.Crowns,
@@ -259,7 +196,7 @@ func (s *Storage) loadParticipants(battleTime string, role string) ([]types.Play
 			&p.ElixirLeaked,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		// Handle NULL values
@@ -275,49 +212,92 @@ func (s *Storage) loadParticipants(battleTime string, role string) ([]types.Play
 			p.TrophyChange = 0
 		}
 
-		cards, err := s.loadDeck(p.Tag, battleTime)
-		if err != nil {
-			return nil, err
+		if d, ok := decks[deckKey{battleID, p.Tag}]; ok {
+			p.Cards = d.cards
+			p.SupportCards = d.support
 		}
-		p.Cards = cards
 
-		players = append(players, p)
+		i, ok := index[battleID]
+		if !ok {
+			continue
+		}
+		if role == "team" {
+			battles[i].Team = append(battles[i].Team, p)
+		} else {
+			battles[i].Opponent = append(battles[i].Opponent, p)
+		}
 	}
 
-	return players, nil
+	return rows.Err()
 }
 
-// loadDeck retrieves all cards in a player's deck for a specific battle.
-func (s *Storage) loadDeck(playerTag string, battleTime string) ([]types.Card, error) {
-	rows, err := s.DB.Query(`
-		SELECT c.id, c.name, c.maxLevel, c.rarity, c.elixirCost, bd.card_level
+// loadDecks retrieves the decks and support cards of every participant in the battles
+// selected by idQuery, in the order they were played. Card metadata comes from the synced
+// card catalog when available, falling back to the snapshot recorded from the battle log.
+func (s *Storage) loadDecks(idQuery string, args []interface{}) (map[deckKey]*deck, error) {
+	rows, err := s.DB.Query(s.rebind(`
+		SELECT bd.battle_id,
+		       bd.player_tag,
+		       c.id,
+		       COALESCE(cc.name, c.name),
+		       COALESCE(cc.max_level, c.maxLevel),
+		       COALESCE(cc.rarity, c.rarity),
+		       COALESCE(cc.elixir_cost, c.elixirCost),
+		       COALESCE(cc.max_evolution_level, c.max_evolution_level),
+		       COALESCE(cc.icon_url, c.icon_url),
+		       COALESCE(cc.evolution_icon_url, c.evolution_icon_url),
+		       bd.card_level,
+		       bd.evolution_level,
+		       bd.star_level,
+		       bd.support
 		FROM battle_decks bd
 		JOIN cards c ON c.id = bd.card_id
-		WHERE bd.battleTime = ? AND bd.player_tag = ?
-		ORDER BY c.id
-	`, battleTime, playerTag)
+		LEFT JOIN card_catalog cc ON cc.id = bd.card_id
+		WHERE bd.battle_id IN (`+idQuery+`)
+		ORDER BY bd.battle_id, bd.player_tag, bd.support, bd.slot
+	`), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var cards []types.Card
+	decks := make(map[deckKey]*deck)
 
 	for rows.Next() {
+		var key deckKey
 		var c types.Card
+		var support bool
 		err := rows.Scan(
+			&key.battleID,
+			&key.playerTag,
 			&c.ID,
 			&c.Name,
 			&c.MaxLevel,
 			&c.Rarity,
 			&c.ElixirCost,
+			&c.MaxEvolutionLevel,
+			&c.IconURLs.Medium,
+			&c.IconURLs.EvolutionMedium,
 			&c.Level,
+			&c.EvolutionLevel,
+			&c.StarLevel,
+			&support,
 		)
 		if err != nil {
 			return nil, err
 		}
-		cards = append(cards, c)
+
+		d, ok := decks[key]
+		if !ok {
+			d = &deck{}
+			decks[key] = d
+		}
+		if support {
+			d.support = append(d.support, c)
+		} else {
+			d.cards = append(d.cards, c)
+		}
 	}
 
-	return cards, nil
+	return decks, rows.Err()
 }
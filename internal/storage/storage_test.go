@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// newTestStorage returns a migrated SQLite database in a temporary directory.
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := NewStorage(db)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return s
+}
+
+// testBattle returns a Ladder battle between tag and opponent at battleTime.
+func testBattle(battleTime, tag, opponent string, crowns, opponentCrowns int32) types.Battle {
+	deck := func(first int32) []types.Card {
+		cards := make([]types.Card, 8)
+		for i := range cards {
+			id := first + int32(i)
+			cards[i] = types.Card{ID: id, Name: fmt.Sprintf("Card %d", id), Level: 11, MaxLevel: 14, Rarity: "Common", ElixirCost: 3}
+		}
+		return cards
+	}
+
+	return types.Battle{
+		BattleTime: battleTime,
+		BattleType: "PvP",
+		Arena:      types.Arena{ID: 54000015, Name: "Royal Arena"},
+		GameMode:   types.GameMode{ID: LadderGameModeID, Name: "Ladder"},
+		Team: []types.Player{{
+			Tag: tag, Name: "Me", StartingTrophies: 5000, TrophyChange: 30, Crowns: crowns, Cards: deck(26000000),
+		}},
+		Opponent: []types.Player{{
+			Tag: opponent, Name: "Them", StartingTrophies: 5010, TrophyChange: -30, Crowns: opponentCrowns, Cards: deck(26000010),
+		}},
+	}
+}
@@ -0,0 +1,92 @@
+// Package storage provides database operations for saving and retrieving Clash Royale battle data.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+
+	_ "github.com/glebarez/go-sqlite"  // "sqlite" driver
+	_ "github.com/jackc/pgx/v5/stdlib" // "pgx" driver
+)
+
+// Store is the storage backend used by analytics, the TUI and the CLI.
+// *Storage implements it for SQLite and PostgreSQL; see Open.
+type Store interface {
+	// Schema
+	Init() error
+	Migrate() ([]Migration, error)
+	MigrationStatus() ([]MigrationStatus, error)
+
+	// Battles
+	InsertBattle(b *types.Battle) error
+	InsertBattles(ctx context.Context, battles []types.Battle) (InsertResult, error)
+	GetBattlesForPlayer(tag string, filter BattleFilter) ([]types.Battle, error)
+	GetBattlesPage(tag string, filter BattleFilter, page Page) ([]types.Battle, string, error)
+	QueryBattles(q BattleQuery, page Page) ([]types.Battle, string, error)
+	CountBattles(q BattleQuery) (int, error)
+	RawBattle(battleID string) (json.RawMessage, error)
+	Reparse(ctx context.Context) (int, error)
+
+	// Ingestion coverage
+	NewestBattleTime(tag string) (string, error)
+	RecordGap(g IngestionGap) error
+	IngestionGaps(tag string) ([]IngestionGap, error)
+	StartRun(tag string, startedAt time.Time) (int64, error)
+	FinishRun(r IngestionRun) error
+	LatestFetchedBattle(tag string) (string, error)
+	IngestionRuns(f RunFilter) ([]IngestionRun, error)
+
+	// Player profiles
+	SavePlayerSnapshot(p *types.PlayerProfile, takenAt time.Time) error
+	LatestPlayerSnapshot(tag string) (*PlayerSnapshot, error)
+	PlayerSnapshots(tag string, since time.Time) ([]PlayerSnapshot, error)
+
+	// Cards
+	SyncCardCatalog(cards []types.Card) error
+	GetCardCatalog() ([]types.Card, error)
+	FindCard(name string) (int32, error)
+
+	// Clans
+	SaveClan(clan *types.Clan, members []types.ClanMember) error
+	SaveRiverRace(clanTag, raceID string, participants []types.RiverRaceParticipant) error
+	GetClanMembers(clanTag string) ([]types.ClanMember, error)
+
+	Close() error
+}
+
+var _ Store = (*Storage)(nil)
+
+// Open connects to the database named by dsn without touching its schema.
+// A postgres:// or postgresql:// URL selects PostgreSQL; anything else is
+// treated as the path of a SQLite database file.
+func Open(dsn string) (*Storage, error) {
+	if IsPostgresDSN(dsn) {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		return NewPostgresStorage(db), nil
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return NewStorage(db), nil
+}
+
+// IsPostgresDSN reports whether dsn selects the PostgreSQL backend.
+func IsPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// Close closes the underlying database connection.
+func (s *Storage) Close() error {
+	return s.DB.Close()
+}
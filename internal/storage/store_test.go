@@ -0,0 +1,442 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/elliot727/log-gob/internal/types"
+)
+
+// storeBackends are the databases every Store implementation is checked against.
+var storeBackends = []struct {
+	name string
+	open func(t *testing.T) *Storage
+}{
+	{"sqlite", newTestStorage},
+	{"postgres", newPostgresTestStorage},
+}
+
+// storeConformance lists behavior every backend must share. Each case gets an empty, migrated store.
+var storeConformance = []struct {
+	name string
+	run  func(t *testing.T, s *Storage)
+}{
+	{"migrations applied", testStoreMigrations},
+	{"battles round trip", testStoreBattles},
+	{"battle pages", testStorePages},
+	{"battle queries", testStoreQueries},
+	{"ingestion filter", testStoreIngestFilter},
+	{"raw battle archive", testStoreRawBattles},
+	{"ingestion gaps", testStoreGaps},
+	{"ingestion runs", testStoreRuns},
+	{"player snapshots", testStoreSnapshots},
+	{"card catalog", testStoreCards},
+	{"clans", testStoreClans},
+}
+
+func TestStoreConformance(t *testing.T) {
+	for _, backend := range storeBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, tc := range storeConformance {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, backend.open(t))
+				})
+			}
+		})
+	}
+}
+
+func mustInsert(t *testing.T, s Store, battles ...types.Battle) InsertResult {
+	t.Helper()
+	res, err := s.InsertBattles(context.Background(), battles)
+	if err != nil {
+		t.Fatalf("InsertBattles: %v", err)
+	}
+	return res
+}
+
+func testStoreMigrations(t *testing.T, s *Storage) {
+	status, err := s.MigrationStatus()
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	if len(status) != len(s.Migrations()) {
+		t.Errorf("MigrationStatus lists %d migrations, want %d", len(status), len(s.Migrations()))
+	}
+	for _, m := range status {
+		if !m.Applied || m.AppliedAt.IsZero() {
+			t.Errorf("migration %d (%s) not applied", m.Version, m.Name)
+		}
+	}
+	if ran, err := s.Migrate(); err != nil || len(ran) != 0 {
+		t.Errorf("Migrate on a current database ran %d migrations, err %v", len(ran), err)
+	}
+}
+
+func testStoreBattles(t *testing.T, s *Storage) {
+	older := testBattle("20251012T190501.000Z", "#2PP", "#8QQ", 3, 1)
+	newer := testBattle("20251012T191002.000Z", "#2PP", "#9RR", 0, 2)
+
+	if res := mustInsert(t, s, older, newer); res != (InsertResult{Inserted: 2}) {
+		t.Errorf("first insert = %+v", res)
+	}
+	if res := mustInsert(t, s, newer); res != (InsertResult{Duplicates: 1}) {
+		t.Errorf("second insert = %+v", res)
+	}
+
+	battles, err := s.GetBattlesForPlayer("#2PP", AllBattles)
+	if err != nil {
+		t.Fatalf("GetBattlesForPlayer: %v", err)
+	}
+	if len(battles) != 2 {
+		t.Fatalf("got %d battles, want 2", len(battles))
+	}
+	if battles[0].BattleTime != newer.BattleTime || battles[1].BattleTime != older.BattleTime {
+		t.Errorf("battles not newest first: %s, %s", battles[0].BattleTime, battles[1].BattleTime)
+	}
+
+	got, want := battles[1], older
+	if got.ID() != want.ID() || got.BattleType != want.BattleType || got.Arena != want.Arena || got.GameMode != want.GameMode {
+		t.Errorf("battle = %s %s %+v %+v, want %s %s %+v %+v", got.ID(), got.BattleType, got.Arena, got.GameMode,
+			want.ID(), want.BattleType, want.Arena, want.GameMode)
+	}
+	for _, side := range []struct{ got, want types.Player }{{got.Team[0], want.Team[0]}, {got.Opponent[0], want.Opponent[0]}} {
+		g, w := side.got, side.want
+		if g.Tag != w.Tag || g.Name != w.Name || g.Crowns != w.Crowns || g.StartingTrophies != w.StartingTrophies || g.TrophyChange != w.TrophyChange {
+			t.Errorf("player = %+v, want %+v", g, w)
+		}
+		if len(g.Cards) != len(w.Cards) {
+			t.Fatalf("%s has %d cards, want %d", g.Tag, len(g.Cards), len(w.Cards))
+		}
+		for i := range w.Cards {
+			if g.Cards[i].ID != w.Cards[i].ID || g.Cards[i].Level != w.Cards[i].Level {
+				t.Errorf("%s card %d = %+v, want %+v", g.Tag, i, g.Cards[i], w.Cards[i])
+			}
+		}
+	}
+
+	n, err := s.CountBattles(BattleQuery{PlayerTag: "#2PP"})
+	if err != nil || n != 2 {
+		t.Errorf("CountBattles = %d, %v; want 2", n, err)
+	}
+	newest, err := s.NewestBattleTime("#2PP")
+	if err != nil || newest != newer.BattleTime {
+		t.Errorf("NewestBattleTime = %q, %v; want %q", newest, err, newer.BattleTime)
+	}
+}
+
+func testStorePages(t *testing.T, s *Storage) {
+	var battles []types.Battle
+	for i := range 5 {
+		battles = append(battles, testBattle(fmt.Sprintf("20251012T19%02d00.000Z", i), "#2PP", "#8QQ", 1, 0))
+	}
+	mustInsert(t, s, battles...)
+
+	var seen []string
+	cursor := ""
+	for page := 0; ; page++ {
+		got, next, err := s.GetBattlesPage("#2PP", AllBattles, Page{Limit: 2, Before: cursor})
+		if err != nil {
+			t.Fatalf("GetBattlesPage: %v", err)
+		}
+		for _, b := range got {
+			seen = append(seen, b.BattleTime)
+		}
+		if next == "" {
+			break
+		}
+		if page > 5 {
+			t.Fatal("paging did not end")
+		}
+		cursor = next
+	}
+
+	if len(seen) != len(battles) {
+		t.Fatalf("paged through %d battles, want %d", len(seen), len(battles))
+	}
+	for i, bt := range seen {
+		if want := battles[len(battles)-1-i].BattleTime; bt != want {
+			t.Errorf("battle %d = %s, want %s", i, bt, want)
+		}
+	}
+}
+
+func testStoreQueries(t *testing.T, s *Storage) {
+	win := testBattle("20251012T190000.000Z", "#2PP", "#8QQ", 3, 1)
+	loss := testBattle("20251012T191000.000Z", "#2PP", "#9RR", 0, 2)
+	friendly := testBattle("20251012T192000.000Z", "#2PP", "#8QQ", 1, 1)
+	friendly.BattleType = "friendly"
+	friendly.Team[0].Cards = friendly.Team[0].Cards[:4]
+	mustInsert(t, s, win, loss, friendly)
+
+	tests := []struct {
+		name string
+		q    BattleQuery
+		want []string
+	}{
+		{"wins", BattleQuery{Result: Win}, []string{win.BattleTime}},
+		{"losses", BattleQuery{Result: Loss}, []string{loss.BattleTime}},
+		{"draws", BattleQuery{Result: Draw}, []string{friendly.BattleTime}},
+		{"ladder", BattleQuery{Filter: LadderBattles}, []string{loss.BattleTime, win.BattleTime}},
+		{"opponent", BattleQuery{OpponentTag: "#8QQ"}, []string{friendly.BattleTime, win.BattleTime}},
+		{"since", BattleQuery{Since: time.Date(2025, 10, 12, 19, 10, 0, 0, time.UTC)}, []string{friendly.BattleTime, loss.BattleTime}},
+		{"until", BattleQuery{Until: time.Date(2025, 10, 12, 19, 10, 0, 0, time.UTC)}, []string{win.BattleTime}},
+		{"card", BattleQuery{Cards: []int32{26000007}}, []string{loss.BattleTime, win.BattleTime}},
+		{"opponent card", BattleQuery{OpponentCards: []int32{26000010}}, []string{friendly.BattleTime, loss.BattleTime, win.BattleTime}},
+		{"deck", BattleQuery{DeckHash: win.Team[0].DeckHash()}, []string{loss.BattleTime, win.BattleTime}},
+		{"opponent's side", BattleQuery{PlayerTag: "#8QQ", Result: Loss}, []string{win.BattleTime}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.q.PlayerTag == "" {
+				tt.q.PlayerTag = "#2PP"
+			}
+			battles, _, err := s.QueryBattles(tt.q, Page{})
+			if err != nil {
+				t.Fatalf("QueryBattles: %v", err)
+			}
+			var got []string
+			for _, b := range battles {
+				got = append(got, b.BattleTime)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("QueryBattles = %v, want %v", got, tt.want)
+			}
+			n, err := s.CountBattles(tt.q)
+			if err != nil || n != len(tt.want) {
+				t.Errorf("CountBattles = %d, %v; want %d", n, err, len(tt.want))
+			}
+		})
+	}
+
+	if _, _, err := s.QueryBattles(BattleQuery{}, Page{}); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("QueryBattles without a player: err = %v, want ErrNoPlayer", err)
+	}
+}
+
+func testStoreIngestFilter(t *testing.T, s *Storage) {
+	s.Ingest = LadderBattles
+
+	friendly := testBattle("20251012T190000.000Z", "#2PP", "#8QQ", 1, 0)
+	friendly.BattleType = "friendly"
+	if err := s.InsertBattle(&friendly); !errors.Is(err, ErrFiltered) {
+		t.Errorf("InsertBattle(friendly) = %v, want ErrFiltered", err)
+	}
+
+	ladder := testBattle("20251012T191000.000Z", "#2PP", "#8QQ", 1, 0)
+	if err := s.InsertBattle(&ladder); err != nil {
+		t.Errorf("InsertBattle(ladder) = %v", err)
+	}
+	if res := mustInsert(t, s, friendly, ladder); res != (InsertResult{Duplicates: 1, Skipped: 1}) {
+		t.Errorf("InsertBattles = %+v", res)
+	}
+}
+
+func testStoreRawBattles(t *testing.T, s *Storage) {
+	data, err := json.Marshal(testBattle("20251012T190000.000Z", "#2PP", "#8QQ", 1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b types.Battle
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	mustInsert(t, s, b)
+
+	raw, err := s.RawBattle(b.ID())
+	if err != nil {
+		t.Fatalf("RawBattle: %v", err)
+	}
+	if string(raw) != string(data) {
+		t.Errorf("RawBattle = %s, want %s", raw, data)
+	}
+	if _, err := s.RawBattle("unknown"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RawBattle(unknown) = %v, want sql.ErrNoRows", err)
+	}
+
+	n, err := s.Reparse(context.Background())
+	if err != nil || n != 1 {
+		t.Errorf("Reparse = %d, %v; want 1", n, err)
+	}
+	if n, err := s.CountBattles(BattleQuery{PlayerTag: "#2PP"}); err != nil || n != 1 {
+		t.Errorf("CountBattles after Reparse = %d, %v; want 1", n, err)
+	}
+}
+
+func testStoreGaps(t *testing.T, s *Storage) {
+	gap := IngestionGap{PlayerTag: "#2PP", After: "20251012T190000.000Z", Before: "20251013T190000.000Z", DetectedAt: time.Now()}
+	for range 2 {
+		if err := s.RecordGap(gap); err != nil {
+			t.Fatalf("RecordGap: %v", err)
+		}
+	}
+	if err := s.RecordGap(IngestionGap{PlayerTag: "#8QQ", After: "a", Before: "b", DetectedAt: time.Now()}); err != nil {
+		t.Fatalf("RecordGap: %v", err)
+	}
+
+	gaps, err := s.IngestionGaps("#2PP")
+	if err != nil {
+		t.Fatalf("IngestionGaps: %v", err)
+	}
+	if len(gaps) != 1 || gaps[0].After != gap.After || gaps[0].Before != gap.Before {
+		t.Errorf("IngestionGaps(#2PP) = %+v", gaps)
+	}
+	if all, err := s.IngestionGaps(""); err != nil || len(all) != 2 {
+		t.Errorf("IngestionGaps(\"\") = %d gaps, %v; want 2", len(all), err)
+	}
+}
+
+func testStoreRuns(t *testing.T, s *Storage) {
+	start := time.Date(2025, 10, 12, 19, 0, 0, 0, time.UTC)
+
+	ok, err := s.StartRun("#2PP", start)
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	err = s.FinishRun(IngestionRun{ID: ok, FinishedAt: start.Add(time.Second), APIStatus: 200,
+		Fetched: 25, Inserted: 3, Duplicates: 20, Skipped: 2, LatestBattle: "20251012T185000.000Z"})
+	if err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+
+	failed, err := s.StartRun("#2PP", start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+	if err := s.FinishRun(IngestionRun{ID: failed, FinishedAt: start.Add(2 * time.Minute), Error: "boom"}); err != nil {
+		t.Fatalf("FinishRun: %v", err)
+	}
+
+	if _, err := s.StartRun("#8QQ", start.Add(3*time.Minute)); err != nil {
+		t.Fatalf("StartRun: %v", err)
+	}
+
+	runs, err := s.IngestionRuns(RunFilter{PlayerTag: "#2PP"})
+	if err != nil {
+		t.Fatalf("IngestionRuns: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != failed || runs[1].ID != ok {
+		t.Fatalf("IngestionRuns(#2PP) = %+v", runs)
+	}
+	r := runs[1]
+	if !r.StartedAt.Equal(start) || r.APIStatus != 200 || r.Fetched != 25 || r.Inserted != 3 ||
+		r.Duplicates != 20 || r.Skipped != 2 || r.LatestBattle != "20251012T185000.000Z" || r.Failed() {
+		t.Errorf("run = %+v", r)
+	}
+
+	failedRuns, err := s.IngestionRuns(RunFilter{Failed: true})
+	if err != nil {
+		t.Fatalf("IngestionRuns: %v", err)
+	}
+	if len(failedRuns) != 2 || failedRuns[0].PlayerTag != "#8QQ" || failedRuns[1].Error != "boom" {
+		t.Errorf("failed runs = %+v", failedRuns)
+	}
+	if limited, err := s.IngestionRuns(RunFilter{Limit: 1}); err != nil || len(limited) != 1 {
+		t.Errorf("IngestionRuns with limit 1 = %d runs, %v", len(limited), err)
+	}
+
+	latest, err := s.LatestFetchedBattle("#2PP")
+	if err != nil || latest != "20251012T185000.000Z" {
+		t.Errorf("LatestFetchedBattle = %q, %v", latest, err)
+	}
+}
+
+func testStoreSnapshots(t *testing.T, s *Storage) {
+	if _, err := s.LatestPlayerSnapshot("#2PP"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("LatestPlayerSnapshot with none taken = %v, want sql.ErrNoRows", err)
+	}
+
+	profile := types.PlayerProfile{
+		Tag: "#2PP", Name: "Sample", ExpLevel: 50, Trophies: 5000, BestTrophies: 5100, Wins: 10, Losses: 5,
+		Arena: types.Arena{ID: 54000015, Name: "Royal Arena"},
+		Clan:  &types.ClanRef{Tag: "#CLAN", Name: "Clan"},
+		Cards: []types.Card{{ID: 26000000, Name: "Knight", Level: 14, MaxLevel: 14, Rarity: "common", ElixirCost: 3}},
+	}
+	day := time.Date(2025, 10, 12, 0, 0, 0, 0, time.UTC)
+	if err := s.SavePlayerSnapshot(&profile, day); err != nil {
+		t.Fatalf("SavePlayerSnapshot: %v", err)
+	}
+	profile.Trophies = 5030
+	if err := s.SavePlayerSnapshot(&profile, day.Add(24*time.Hour)); err != nil {
+		t.Fatalf("SavePlayerSnapshot: %v", err)
+	}
+
+	latest, err := s.LatestPlayerSnapshot("#2PP")
+	if err != nil {
+		t.Fatalf("LatestPlayerSnapshot: %v", err)
+	}
+	if !latest.TakenAt.Equal(day.Add(24*time.Hour)) || latest.Profile.Trophies != 5030 || len(latest.Profile.Cards) != 1 {
+		t.Errorf("latest snapshot = %+v", latest)
+	}
+
+	snaps, err := s.PlayerSnapshots("#2PP", day.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("PlayerSnapshots: %v", err)
+	}
+	if len(snaps) != 1 || snaps[0].Profile.Trophies != 5030 {
+		t.Errorf("snapshots since %v = %+v", day.Add(time.Hour), snaps)
+	}
+}
+
+func testStoreCards(t *testing.T, s *Storage) {
+	cards := []types.Card{
+		{ID: 26000021, Name: "Hog Rider", MaxLevel: 14, Rarity: "rare", ElixirCost: 4},
+		{ID: 26000000, Name: "Knight", MaxLevel: 14, Rarity: "common", ElixirCost: 3},
+	}
+	if err := s.SyncCardCatalog(cards); err != nil {
+		t.Fatalf("SyncCardCatalog: %v", err)
+	}
+	if err := s.SyncCardCatalog(cards[:1]); err != nil {
+		t.Fatalf("SyncCardCatalog: %v", err)
+	}
+
+	catalog, err := s.GetCardCatalog()
+	if err != nil {
+		t.Fatalf("GetCardCatalog: %v", err)
+	}
+	if len(catalog) != 2 || catalog[0].ID != 26000000 || catalog[1].Name != "Hog Rider" {
+		t.Errorf("GetCardCatalog = %+v", catalog)
+	}
+
+	if id, err := s.FindCard("hog rider"); err != nil || id != 26000021 {
+		t.Errorf("FindCard(hog rider) = %d, %v", id, err)
+	}
+	if _, err := s.FindCard("Nope"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("FindCard(Nope) = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testStoreClans(t *testing.T, s *Storage) {
+	clan := types.Clan{Tag: "#CLAN", Name: "Clan", Type: "open", Members: 2}
+	members := []types.ClanMember{
+		{Tag: "#2PP", Name: "Sample", Role: "leader", ExpLevel: 50, Trophies: 5000, ClanRank: 1, Arena: types.Arena{ID: 54000015, Name: "Royal Arena"}},
+		{Tag: "#8QQ", Name: "Rival", Role: "member", ExpLevel: 40, Trophies: 4000, ClanRank: 2, Arena: types.Arena{ID: 54000015, Name: "Royal Arena"}},
+	}
+	if err := s.SaveClan(&clan, members); err != nil {
+		t.Fatalf("SaveClan: %v", err)
+	}
+	if err := s.SaveClan(&clan, members[:1]); err != nil {
+		t.Fatalf("SaveClan: %v", err)
+	}
+
+	got, err := s.GetClanMembers("#CLAN")
+	if err != nil {
+		t.Fatalf("GetClanMembers: %v", err)
+	}
+	if len(got) != 1 || got[0].Tag != "#2PP" || got[0].Role != "leader" || got[0].Trophies != 5000 {
+		t.Errorf("GetClanMembers = %+v", got)
+	}
+
+	participants := []types.RiverRaceParticipant{{Tag: "#2PP", Name: "Sample", Fame: 1600, DecksUsed: 4}}
+	for range 2 {
+		if err := s.SaveRiverRace("#CLAN", CurrentRaceID, participants); err != nil {
+			t.Fatalf("SaveRiverRace: %v", err)
+		}
+	}
+}
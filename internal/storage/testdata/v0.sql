@@ -0,0 +1,64 @@
+-- The schema created by Storage.Init before versioned migrations, with one stored battle.
+CREATE TABLE IF NOT EXISTS arenas (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS gamemodes (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS players (
+	tag TEXT PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS cards (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	maxLevel INTEGER NOT NULL,
+	rarity TEXT NOT NULL,
+	elixirCost INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS battles (
+	battleTime TEXT PRIMARY KEY,
+	type TEXT NOT NULL,
+	arena_id INTEGER NOT NULL,
+	gamemode_id INTEGER NOT NULL,
+	FOREIGN KEY (arena_id) REFERENCES arenas(id),
+	FOREIGN KEY (gamemode_id) REFERENCES gamemodes(id)
+);
+CREATE TABLE IF NOT EXISTS battle_participants (
+	battleTime TEXT NOT NULL,
+	player_tag TEXT NOT NULL,
+	role TEXT NOT NULL,
+	crowns INTEGER NOT NULL,
+	startingTrophies INTEGER NOT NULL,
+	trophyChange INTEGER NOT NULL,
+	elixirLeaked REAL NOT NULL,
+	PRIMARY KEY (battleTime, player_tag),
+	FOREIGN KEY (battleTime) REFERENCES battles(battleTime) ON DELETE CASCADE,
+	FOREIGN KEY (player_tag) REFERENCES players(tag)
+);
+CREATE TABLE IF NOT EXISTS battle_decks (
+	battleTime TEXT NOT NULL,
+	player_tag TEXT NOT NULL,
+	card_id INTEGER NOT NULL,
+	card_level INTEGER NOT NULL,
+	PRIMARY KEY (battleTime, player_tag, card_id),
+	FOREIGN KEY (battleTime, player_tag) REFERENCES battle_participants(battleTime, player_tag) ON DELETE CASCADE,
+	FOREIGN KEY (card_id) REFERENCES cards(id)
+);
+
+INSERT INTO arenas (id, name) VALUES (54000015, 'Royal Arena');
+INSERT INTO gamemodes (id, name) VALUES (72000006, 'Ladder');
+INSERT INTO players (tag, name) VALUES ('#2PP', 'Sample'), ('#8QQ', 'Rival');
+INSERT INTO cards (id, name, maxLevel, rarity, elixirCost) VALUES
+	(26000000, 'Knight', 14, 'common', 3),
+	(26000021, 'Hog Rider', 12, 'rare', 4);
+INSERT INTO battles (battleTime, type, arena_id, gamemode_id) VALUES ('20251012T190501.000Z', 'PvP', 54000015, 72000006);
+INSERT INTO battle_participants (battleTime, player_tag, role, crowns, startingTrophies, trophyChange, elixirLeaked) VALUES
+	('20251012T190501.000Z', '#2PP', 'team', 3, 5000, 30, 1.5),
+	('20251012T190501.000Z', '#8QQ', 'opponent', 1, 5010, -30, 0.25);
+INSERT INTO battle_decks (battleTime, player_tag, card_id, card_level) VALUES
+	('20251012T190501.000Z', '#2PP', 26000000, 14),
+	('20251012T190501.000Z', '#2PP', 26000021, 12),
+	('20251012T190501.000Z', '#8QQ', 26000000, 13);
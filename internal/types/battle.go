@@ -1,6 +1,19 @@
 // Package types defines the data structures used throughout the application for Clash Royale data.
 package types
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// BattleTimeLayout is the time.Parse layout of Battle.BattleTime, e.g. "20251012T190501.000Z".
+// Times in this layout sort lexically in chronological order.
+const BattleTimeLayout = "20060102T150405.000Z"
+
 // Battle represents a single battle in Clash Royale, including the time, type, arena, game mode, and participants.
 type Battle struct {
 	BattleTime string   `json:"battleTime"` // The time when the battle occurred in ISO 8601 format
@@ -9,4 +22,73 @@ type Battle struct {
 	GameMode   GameMode `json:"gameMode"`   // The game mode of the battle
 	Team       []Player `json:"team"`       // The players on the user's team
 	Opponent   []Player `json:"opponent"`   // The players on the opposing team
+
+	// Raw is the JSON the battle was decoded from, including fields not modeled above.
+	// It is nil for battles built in code or loaded from normalized storage.
+	Raw json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes a battle log entry and keeps a copy of it in Raw.
+func (b *Battle) UnmarshalJSON(data []byte) error {
+	type battle Battle // drops this method to avoid recursion
+	if err := json.Unmarshal(data, (*battle)(b)); err != nil {
+		return err
+	}
+	b.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Time parses BattleTime.
+func (b *Battle) Time() (time.Time, error) {
+	return time.Parse(BattleTimeLayout, b.BattleTime)
+}
+
+// ID returns the battle's stable identifier. See BattleID.
+func (b *Battle) ID() string {
+	tags := make([]string, 0, len(b.Team)+len(b.Opponent))
+	for _, p := range b.Team {
+		tags = append(tags, p.Tag)
+	}
+	for _, p := range b.Opponent {
+		tags = append(tags, p.Tag)
+	}
+	return BattleID(b.BattleTime, tags)
+}
+
+// BattleID derives a battle's identifier from its time and participant tags.
+// Tags are sorted first, so the same battle fetched from either player's battle
+// log gets the same ID, while different battles finishing in the same second do not collide.
+func BattleID(battleTime string, tags []string) string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	h.Write([]byte(battleTime))
+	for _, tag := range sorted {
+		h.Write([]byte{0})
+		h.Write([]byte(tag))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// DeckHash identifies a deck by its cards regardless of their order, so the same
+// eight cards always hash the same. Support cards are not part of the deck.
+// An empty deck hashes to "".
+func DeckHash(cards []Card) string {
+	if len(cards) == 0 {
+		return ""
+	}
+
+	ids := make([]int, len(cards))
+	for i, c := range cards {
+		ids[i] = int(c.ID)
+	}
+	sort.Ints(ids)
+
+	h := sha256.New()
+	for _, id := range ids {
+		h.Write([]byte(strconv.Itoa(id)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
 }
@@ -9,4 +9,15 @@ type Card struct {
 	MaxLevel   uint32 `json:"maxLevel"`   // The maximum possible level for this card in battles
 	Rarity     string `json:"rarity"`     // The rarity of the card (e.g., "Common", "Rare", "Epic", "Legendary")
 	ElixirCost int32  `json:"elixirCost"` // The elixir cost to play this card
+
+	EvolutionLevel    int32    `json:"evolutionLevel,omitempty"`    // The evolution level used in battle, zero if not evolved
+	MaxEvolutionLevel int32    `json:"maxEvolutionLevel,omitempty"` // The highest evolution level, zero if the card cannot evolve
+	StarLevel         int32    `json:"starLevel,omitempty"`         // Star level (cosmetic upgrade), zero if none
+	Count             int32    `json:"count,omitempty"`             // Copies owned towards the next upgrade, in a player's collection
+	IconURLs          IconURLs `json:"iconUrls"`                    // Card artwork
+}
+
+// Evolved reports whether the card was played in its evolved form.
+func (c Card) Evolved() bool {
+	return c.EvolutionLevel > 0
 }
@@ -0,0 +1,32 @@
+// Package types defines the data structures used throughout the application for Clash Royale data.
+package types
+
+// Clan represents a clan as returned by /v1/clans/{tag}.
+type Clan struct {
+	Tag              string       `json:"tag"`              // The clan's unique tag identifier
+	Name             string       `json:"name"`             // The clan's name
+	Type             string       `json:"type"`             // Join type: "open", "inviteOnly" or "closed"
+	Description      string       `json:"description"`      // The clan description
+	BadgeID          int32        `json:"badgeId"`          // The clan badge identifier
+	ClanScore        int32        `json:"clanScore"`        // Clan trophies from members
+	ClanWarTrophies  int32        `json:"clanWarTrophies"`  // Clan war trophies
+	RequiredTrophies int32        `json:"requiredTrophies"` // Trophies required to join
+	DonationsPerWeek int32        `json:"donationsPerWeek"` // Donations made this week
+	Members          int32        `json:"members"`          // Number of members
+	MemberList       []ClanMember `json:"memberList"`       // The clan's members
+}
+
+// ClanMember represents a member of a clan as returned by /v1/clans/{tag}/members.
+type ClanMember struct {
+	Tag               string `json:"tag"`               // The player's unique tag identifier
+	Name              string `json:"name"`              // The player's name
+	Role              string `json:"role"`              // "member", "elder", "coLeader" or "leader"
+	LastSeen          string `json:"lastSeen"`          // When the player was last online, in API time format
+	ExpLevel          int32  `json:"expLevel"`          // King level
+	Trophies          int32  `json:"trophies"`          // Current trophy count
+	Arena             Arena  `json:"arena"`             // The player's current arena
+	ClanRank          int32  `json:"clanRank"`          // Rank within the clan by trophies
+	PreviousClanRank  int32  `json:"previousClanRank"`  // Rank within the clan last season
+	Donations         int32  `json:"donations"`         // Cards donated this week
+	DonationsReceived int32  `json:"donationsReceived"` // Cards received this week
+}
@@ -12,3 +12,8 @@ type Player struct {
 	ElixirLeaked     float64 `json:"elixirLeaked"`     // Amount of elixir leaked to the opponent (0-1 scale)
 	SupportCards     []Card  `json:"supportCards"`     // Support cards in the player's deck (if any)
 }
+
+// DeckHash returns the hash of the player's deck. See DeckHash.
+func (p *Player) DeckHash() string {
+	return DeckHash(p.Cards)
+}
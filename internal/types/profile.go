@@ -0,0 +1,57 @@
+// Package types defines the data structures used throughout the application for Clash Royale data.
+package types
+
+// PlayerProfile represents a player's profile as returned by /v1/players/{tag}.
+type PlayerProfile struct {
+	Tag                  string   `json:"tag"`            // The player's unique tag identifier
+	Name                 string   `json:"name"`           // The player's name
+	ExpLevel             int32    `json:"expLevel"`       // King level
+	ExpPoints            int32    `json:"expPoints"`      // Experience towards the next level
+	TotalExpPoints       int32    `json:"totalExpPoints"` // Experience earned overall
+	StarPoints           int32    `json:"starPoints"`     // Star points available to spend
+	Trophies             int32    `json:"trophies"`       // Current trophy count
+	BestTrophies         int32    `json:"bestTrophies"`   // Highest trophy count ever reached
+	Wins                 int32    `json:"wins"`           // Total wins
+	Losses               int32    `json:"losses"`         // Total losses
+	BattleCount          int32    `json:"battleCount"`    // Total battles played
+	ThreeCrownWins       int32    `json:"threeCrownWins"` // Total three crown wins
+	Role                 string   `json:"role"`           // Role in the player's clan, if any
+	Arena                Arena    `json:"arena"`          // The player's current arena
+	Clan                 *ClanRef `json:"clan,omitempty"` // The player's clan, nil when not in a clan
+	Badges               []Badge  `json:"badges"`         // Badges earned by the player
+	Cards                []Card   `json:"cards"`          // The player's full card collection
+	SupportCards         []Card   `json:"supportCards"`   // The player's support card collection
+	CurrentDeck          []Card   `json:"currentDeck"`    // The deck currently selected
+	CurrentDeckSupport   []Card   `json:"currentDeckSupportCards"`
+	CurrentFavouriteCard Card     `json:"currentFavouriteCard"` // The card shown on the player's profile
+}
+
+// ClanRef is the short clan summary embedded in other API objects.
+type ClanRef struct {
+	Tag     string `json:"tag"`     // The clan's unique tag identifier
+	Name    string `json:"name"`    // The clan's name
+	BadgeID int32  `json:"badgeId"` // The clan badge identifier
+}
+
+// Badge represents a badge shown on a player's profile.
+type Badge struct {
+	Name     string   `json:"name"`     // Internal badge name
+	Level    int32    `json:"level"`    // Current level of a multi-level badge
+	MaxLevel int32    `json:"maxLevel"` // Highest level of a multi-level badge
+	Progress int32    `json:"progress"` // Progress towards the next level
+	Target   int32    `json:"target"`   // Progress required for the next level
+	IconURLs IconURLs `json:"iconUrls"` // Badge artwork
+}
+
+// IconURLs holds the artwork URLs the API returns for cards and badges.
+type IconURLs struct {
+	Medium          string `json:"medium,omitempty"`
+	Large           string `json:"large,omitempty"`
+	EvolutionMedium string `json:"evolutionMedium,omitempty"`
+}
+
+// Chest represents an entry in a player's upcoming chest cycle.
+type Chest struct {
+	Index int32  `json:"index"` // How many chests away this chest is
+	Name  string `json:"name"`  // The chest's name
+}
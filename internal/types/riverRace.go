@@ -0,0 +1,51 @@
+// Package types defines the data structures used throughout the application for Clash Royale data.
+package types
+
+// RiverRace represents a clan's current river race (clan war) as returned by
+// /v1/clans/{tag}/currentriverrace.
+type RiverRace struct {
+	State        string          `json:"state"`        // Race state, e.g. "full" or "matchmaking"
+	SectionIndex int32           `json:"sectionIndex"` // Week of the season, starting at 0
+	PeriodIndex  int32           `json:"periodIndex"`  // Day within the season
+	PeriodType   string          `json:"periodType"`   // "training", "warDay" or "colosseum"
+	Clan         RiverRaceClan   `json:"clan"`         // The requested clan's standing
+	Clans        []RiverRaceClan `json:"clans"`        // Every clan taking part in the race
+}
+
+// RiverRaceClan represents a clan's standing and participants in a river race.
+type RiverRaceClan struct {
+	Tag          string                 `json:"tag"`          // The clan's unique tag identifier
+	Name         string                 `json:"name"`         // The clan's name
+	BadgeID      int32                  `json:"badgeId"`      // The clan badge identifier
+	Fame         int32                  `json:"fame"`         // Fame earned in the race
+	RepairPoints int32                  `json:"repairPoints"` // Repair points earned in the race
+	ClanScore    int32                  `json:"clanScore"`    // Clan war trophies
+	FinishTime   string                 `json:"finishTime"`   // When the clan crossed the finish line, if it did
+	Participants []RiverRaceParticipant `json:"participants"` // Members who took part
+}
+
+// RiverRaceParticipant represents one player's contribution to a river race.
+type RiverRaceParticipant struct {
+	Tag            string `json:"tag"`            // The player's unique tag identifier
+	Name           string `json:"name"`           // The player's name
+	Fame           int32  `json:"fame"`           // Fame earned by the player
+	RepairPoints   int32  `json:"repairPoints"`   // Repair points earned by the player
+	BoatAttacks    int32  `json:"boatAttacks"`    // Boat battles played
+	DecksUsed      int32  `json:"decksUsed"`      // War decks used across the race
+	DecksUsedToday int32  `json:"decksUsedToday"` // War decks used on the current day
+}
+
+// RiverRaceLogEntry represents a finished river race as returned by /v1/clans/{tag}/riverracelog.
+type RiverRaceLogEntry struct {
+	SeasonID     int32               `json:"seasonId"`     // The season the race belonged to
+	SectionIndex int32               `json:"sectionIndex"` // Week of the season, starting at 0
+	CreatedDate  string              `json:"createdDate"`  // When the race finished, in API time format
+	Standings    []RiverRaceStanding `json:"standings"`    // Final standings of every clan
+}
+
+// RiverRaceStanding represents a clan's final position in a finished river race.
+type RiverRaceStanding struct {
+	Rank         int32         `json:"rank"`         // Final position
+	TrophyChange int32         `json:"trophyChange"` // Clan war trophies won or lost
+	Clan         RiverRaceClan `json:"clan"`         // The clan and its participants
+}
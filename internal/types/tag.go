@@ -0,0 +1,34 @@
+// Package types defines the data structures used throughout the application for Clash Royale data.
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidTag is returned by NormalizeTag for tags that cannot be valid.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// tagAlphabet lists every character that can appear in a player or clan tag.
+const tagAlphabet = "0289PYLQGRJCUV"
+
+// NormalizeTag converts a user-supplied player or clan tag into the canonical
+// "#XXXXXXX" form used by the API. It accepts tags with or without the leading
+// '#', in any case, and treats the letter O as the digit 0 (a common typo).
+func NormalizeTag(tag string) (string, error) {
+	t := strings.ToUpper(strings.TrimSpace(tag))
+	t = strings.TrimPrefix(t, "#")
+	t = strings.ReplaceAll(t, "O", "0")
+
+	if len(t) < 3 {
+		return "", fmt.Errorf("%w: %q is too short", ErrInvalidTag, tag)
+	}
+	for _, r := range t {
+		if !strings.ContainsRune(tagAlphabet, r) {
+			return "", fmt.Errorf("%w: %q contains %q", ErrInvalidTag, tag, r)
+		}
+	}
+
+	return "#" + t, nil
+}
@@ -3,12 +3,14 @@ package ui
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/elliot727/log-gob/internal/analytics"
+	"github.com/elliot727/log-gob/internal/logging"
 	"github.com/elliot727/log-gob/internal/storage"
 	"github.com/elliot727/log-gob/internal/types"
 )
@@ -68,42 +70,73 @@ var (
 )
 
 type model struct {
-	storage     *storage.Storage
-	playerTag   string
-	battles     []types.Battle
-	analytics   analytics.Analytics // Store computed analytics
-	currentIdx  int
-	status      string
-	initialized bool
-	showStats   bool // Toggle between detail view and stats view
+	storage       storage.Store
+	players       []string // Tracked player tags; playerTag is one of them
+	playerTag     string
+	battles       []types.Battle
+	analytics     analytics.Analytics // Store computed analytics
+	currentIdx    int
+	status        string
+	initialized   bool
+	showStats     bool // Toggle between detail view and stats view
 	showAnalytics bool // Toggle to show detailed analytics vs basic stats
+	allModes      bool // Show every game mode instead of Ladder only
+	showRuns      bool // Show the ingestion run log instead of battles
+	runs          []storage.IngestionRun
 }
 
 type fetchMsg struct {
-	battles []types.Battle
-	err     error
+	playerTag string
+	battles   []types.Battle
+	err       error
+}
+
+type runsMsg struct {
+	playerTag string
+	runs      []storage.IngestionRun
+	err       error
 }
 
 type statusMsg struct {
 	text string
 }
 
-func InitialModel(s *storage.Storage, playerTag string) model {
+// InitialModel creates the TUI model showing the first of players, which must not be empty.
+// The other players can be switched to with 'P'.
+func InitialModel(s storage.Store, players []string) model {
 	return model{
-		storage:     s,
-		playerTag:   playerTag,
-		battles:     []types.Battle{},
-		analytics:   analytics.Analytics{}, // Initialize with empty analytics
-		currentIdx:  0,
-		status:      "Loading battles...",
-		initialized: false,
-		showStats:   false,
+		storage:       s,
+		players:       players,
+		playerTag:     players[0],
+		battles:       []types.Battle{},
+		analytics:     analytics.Analytics{}, // Initialize with empty analytics
+		currentIdx:    0,
+		status:        "Loading battles...",
+		initialized:   false,
+		showStats:     false,
 		showAnalytics: false,
+		allModes:      false,
+	}
+}
+
+// filter returns the battle filter for the selected mode view.
+func (m model) filter() storage.BattleFilter {
+	if m.allModes {
+		return storage.AllBattles
+	}
+	return storage.LadderBattles
+}
+
+// modeLabel names the selected mode view for status lines.
+func (m model) modeLabel() string {
+	if m.allModes {
+		return "all modes"
 	}
+	return "Ladder"
 }
 
 func (m model) Init() tea.Cmd {
-	return fetchBattles(m.storage, m.playerTag)
+	return fetchBattles(m.storage, m.playerTag, m.filter())
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -125,7 +158,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "r", "R":
-			return m, fetchBattles(m.storage, m.playerTag)
+			if m.showRuns {
+				return m, fetchRuns(m.storage, m.playerTag)
+			}
+			return m, fetchBattles(m.storage, m.playerTag, m.filter())
+		case "m", "M":
+			// Toggle between Ladder-only and all game modes
+			m.allModes = !m.allModes
+			m.currentIdx = 0
+			m.status = fmt.Sprintf("Switched to %s", m.modeLabel())
+			return m, fetchBattles(m.storage, m.playerTag, m.filter())
+		case "p", "P":
+			// Cycle through the tracked players
+			if len(m.players) < 2 {
+				m.status = "Only one player is tracked"
+				break
+			}
+			m.playerTag = m.players[(slices.Index(m.players, m.playerTag)+1)%len(m.players)]
+			m.battles = nil
+			m.analytics = analytics.Analytics{}
+			m.currentIdx = 0
+			m.initialized = false
+			m.runs = nil
+			m.status = fmt.Sprintf("Loading battles for %s...", m.playerTag)
+			if m.showRuns {
+				return m, tea.Batch(fetchBattles(m.storage, m.playerTag, m.filter()), fetchRuns(m.storage, m.playerTag))
+			}
+			return m, fetchBattles(m.storage, m.playerTag, m.filter())
+		case "i", "I":
+			// Toggle the ingestion run log
+			m.showRuns = !m.showRuns
+			if m.showRuns {
+				m.status = "Switched to ingestion runs"
+				return m, fetchRuns(m.storage, m.playerTag)
+			}
+			m.status = "Switched to battles"
 		case "s", "S":
 			// Toggle between stats view and detail view
 			m.showStats = !m.showStats
@@ -145,27 +212,42 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case fetchMsg:
+		if msg.playerTag != m.playerTag {
+			// Loaded for a player switched away from before it finished
+			break
+		}
 		if msg.err != nil {
 			m.status = fmt.Sprintf("Error fetching battles: %v", msg.err)
 		} else {
 			m.battles = msg.battles
 			if len(m.battles) > 0 {
-				m.status = fmt.Sprintf("Fetched %d battles for player %s", len(m.battles), m.playerTag)
+				m.status = fmt.Sprintf("Fetched %d %s battles for player %s", len(m.battles), m.modeLabel(), m.playerTag)
 				// Compute analytics using the fetched battles with a default target
 				// For now, using 7000 as a target (can be made configurable later)
-				analytics, err := analytics.Compute(m.storage, m.playerTag, 7000)
+				analytics, err := analytics.Compute(m.storage, m.playerTag, m.filter(), 7000)
 				if err != nil {
+					slog.Error("Failed to compute analytics", logging.KeyTag, m.playerTag, "err", err)
 					m.status = fmt.Sprintf("Error computing analytics: %v", err)
 				} else {
 					m.analytics = analytics
-					m.status = fmt.Sprintf("Fetched %d battles and computed analytics for %s", len(m.battles), m.playerTag)
+					m.status = fmt.Sprintf("Fetched %d %s battles and computed analytics for %s", len(m.battles), m.modeLabel(), m.playerTag)
 				}
 			} else {
-				m.status = fmt.Sprintf("No battles found for player %s", m.playerTag)
+				m.status = fmt.Sprintf("No %s battles found for player %s", m.modeLabel(), m.playerTag)
 			}
 		}
 		m.initialized = true
 
+	case runsMsg:
+		if msg.playerTag != m.playerTag {
+			break
+		}
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Error loading ingestion runs: %v", msg.err)
+		} else {
+			m.runs = msg.runs
+		}
+
 	case statusMsg:
 		m.status = msg.text
 	}
@@ -180,17 +262,26 @@ func (m model) View() string {
 	s.WriteString(titleStyle.Render("=== Clash Royale Battle Logger ==="))
 	s.WriteString("\n\n")
 
+	if m.showRuns {
+		s.WriteString(DisplayRuns(m.playerTag, m.runs))
+		s.WriteString("\n")
+		s.WriteString(statusStyle.Render(m.status))
+		s.WriteString("\n\n")
+		s.WriteString(helpStyle.Render("Controls: [I] Back to Battles | [P] Player | [R] Refresh | [Q] Quit"))
+		return s.String()
+	}
+
 	if !m.initialized {
 		s.WriteString(statusStyle.Render(m.status))
 		s.WriteString("\n\n")
-		s.WriteString(helpStyle.Render("Press 'R' to refresh, 'Q' to quit"))
+		s.WriteString(helpStyle.Render("Press 'R' to refresh, 'M' to switch modes, 'P' to switch players, 'I' for ingestion runs, 'Q' to quit"))
 		return s.String()
 	}
 
 	if len(m.battles) == 0 {
 		s.WriteString(statusStyle.Render(m.status))
 		s.WriteString("\n\n")
-		s.WriteString(helpStyle.Render("Press 'R' to refresh, 'Q' to quit"))
+		s.WriteString(helpStyle.Render("Press 'R' to refresh, 'M' to switch modes, 'P' to switch players, 'I' for ingestion runs, 'Q' to quit"))
 		return s.String()
 	}
 
@@ -372,16 +463,16 @@ func (m model) View() string {
 				for i := range battle.Team[0].Cards {
 					var teamCardName, teamCardLevel, oppCardName, oppCardLevel string
 					if i < len(battle.Team[0].Cards) {
-						teamCardName = battle.Team[0].Cards[i].Name
-						teamCardLevel = fmt.Sprintf("Lvl %d", battle.Team[0].Cards[i].Level)
+						teamCardName = cardName(battle.Team[0].Cards[i])
+						teamCardLevel = cardLevel(battle.Team[0].Cards[i])
 					} else {
 						teamCardName = ""
 						teamCardLevel = ""
 					}
 
 					if i < len(battle.Opponent[0].Cards) {
-						oppCardName = battle.Opponent[0].Cards[i].Name
-						oppCardLevel = fmt.Sprintf("Lvl %d", battle.Opponent[0].Cards[i].Level)
+						oppCardName = cardName(battle.Opponent[0].Cards[i])
+						oppCardLevel = cardLevel(battle.Opponent[0].Cards[i])
 					} else {
 						oppCardName = ""
 						oppCardLevel = ""
@@ -403,9 +494,9 @@ func (m model) View() string {
 					s.WriteString("\n")
 					for i, card := range battle.Team[0].Cards {
 						if i < 8 { // Limit to first 8 cards to keep it readable
-							cardInfo := fmt.Sprintf("  • %s (Lvl %d)",
-								cardStyle.Render(card.Name),
-								card.Level)
+							cardInfo := fmt.Sprintf("  • %s (%s)",
+								cardStyle.Render(cardName(card)),
+								cardLevel(card))
 							s.WriteString(cardInfo)
 							s.WriteString("\n")
 						}
@@ -418,9 +509,9 @@ func (m model) View() string {
 					s.WriteString("\n")
 					for i, card := range battle.Opponent[0].Cards {
 						if i < 8 { // Limit to first 8 cards to keep it readable
-							cardInfo := fmt.Sprintf("  • %s (Lvl %d)",
-								cardStyle.Render(card.Name),
-								card.Level)
+							cardInfo := fmt.Sprintf("  • %s (%s)",
+								cardStyle.Render(cardName(card)),
+								cardLevel(card))
 							s.WriteString(cardInfo)
 							s.WriteString("\n")
 						}
@@ -428,32 +519,32 @@ func (m model) View() string {
 				}
 			}
 
-			// Show support cards for the first team player (if available)
+			// Show the tower troop for the first team player (if available)
 			if len(battle.Team) > 0 && len(battle.Team[0].SupportCards) > 0 {
 				s.WriteString("\n")
-				s.WriteString(headerStyle.Render("Team Support Cards:"))
+				s.WriteString(headerStyle.Render("Team Tower Troop:"))
 				s.WriteString("\n")
 				for i, card := range battle.Team[0].SupportCards {
 					if i < 8 { // Limit to first 8 cards to keep it readable
-						cardInfo := fmt.Sprintf("  • %s (Lvl %d)",
-							cardStyle.Render(card.Name),
-							card.Level)
+						cardInfo := fmt.Sprintf("  • %s (%s)",
+							cardStyle.Render(cardName(card)),
+							cardLevel(card))
 						s.WriteString(cardInfo)
 						s.WriteString("\n")
 					}
 				}
 			}
 
-			// Show support cards for the first opponent player (if available)
+			// Show the tower troop for the first opponent player (if available)
 			if len(battle.Opponent) > 0 && len(battle.Opponent[0].SupportCards) > 0 {
 				s.WriteString("\n")
-				s.WriteString(headerStyle.Render("Opponent Support Cards:"))
+				s.WriteString(headerStyle.Render("Opponent Tower Troop:"))
 				s.WriteString("\n")
 				for i, card := range battle.Opponent[0].SupportCards {
 					if i < 8 { // Limit to first 8 cards to keep it readable
-						cardInfo := fmt.Sprintf("  • %s (Lvl %d)",
-							cardStyle.Render(card.Name),
-							card.Level)
+						cardInfo := fmt.Sprintf("  • %s (%s)",
+							cardStyle.Render(cardName(card)),
+							cardLevel(card))
 						s.WriteString(cardInfo)
 						s.WriteString("\n")
 					}
@@ -467,23 +558,24 @@ func (m model) View() string {
 	s.WriteString("\n")
 	if m.showStats {
 		if m.showAnalytics {
-			s.WriteString(helpStyle.Render("Controls: [A] Basic Stats | [S] Battle Detail | [R] Refresh | [Q] Quit"))
+			s.WriteString(helpStyle.Render("Controls: [A] Basic Stats | [S] Battle Detail | [M] Modes | [P] Player | [I] Runs | [R] Refresh | [Q] Quit"))
 		} else {
-			s.WriteString(helpStyle.Render("Controls: [A] Detailed Analytics | [S] Battle Detail | [R] Refresh | [Q] Quit"))
+			s.WriteString(helpStyle.Render("Controls: [A] Detailed Analytics | [S] Battle Detail | [M] Modes | [P] Player | [I] Runs | [R] Refresh | [Q] Quit"))
 		}
 	} else {
-		s.WriteString(helpStyle.Render("Controls: [J/K] Navigate | [R] Refresh | [S] Stats | [Q] Quit"))
+		s.WriteString(helpStyle.Render("Controls: [J/K] Navigate | [R] Refresh | [S] Stats | [M] Modes | [P] Player | [I] Runs | [Q] Quit"))
 	}
 
 	return s.String()
 }
 
-func fetchBattles(s *storage.Storage, playerTag string) tea.Cmd {
+func fetchBattles(s storage.Store, playerTag string, filter storage.BattleFilter) tea.Cmd {
 	return func() tea.Msg {
-		battles, err := s.GetBattlesForPlayer(playerTag)
+		battles, err := s.GetBattlesForPlayer(playerTag, filter)
 		if err != nil {
-			log.Printf("Error fetching battles: %v", err)
-			return fetchMsg{battles: nil, err: err}
+			// The program owns the terminal, so this goes to the log file; the error is shown in the status line
+			slog.Error("Failed to load battles", logging.KeyTag, playerTag, "err", err)
+			return fetchMsg{playerTag: playerTag, err: err}
 		}
 
 		// Reverse the order to show most recent first
@@ -491,10 +583,84 @@ func fetchBattles(s *storage.Storage, playerTag string) tea.Cmd {
 			battles[i], battles[j] = battles[j], battles[i]
 		}
 
-		return fetchMsg{battles: battles, err: nil}
+		return fetchMsg{playerTag: playerTag, battles: battles}
+	}
+}
+
+// runsShown is how many ingestion runs the run log panel lists.
+const runsShown = 15
+
+func fetchRuns(s storage.Store, playerTag string) tea.Cmd {
+	return func() tea.Msg {
+		runs, err := s.IngestionRuns(storage.RunFilter{PlayerTag: playerTag, Limit: runsShown})
+		if err != nil {
+			slog.Error("Failed to load ingestion runs", logging.KeyTag, playerTag, "err", err)
+		}
+		return runsMsg{playerTag: playerTag, runs: runs, err: err}
 	}
 }
 
+// DisplayRuns renders the most recent ingestion runs for playerTag.
+func DisplayRuns(playerTag string, runs []storage.IngestionRun) string {
+	var s strings.Builder
+
+	s.WriteString(battleHeaderStyle.Render("INGESTION RUNS: " + playerTag))
+	s.WriteString("\n\n")
+
+	if len(runs) == 0 {
+		s.WriteString(infoStyle.Render("No fetches recorded yet"))
+		s.WriteString("\n")
+		return s.String()
+	}
+
+	s.WriteString(headerStyle.Render(fmt.Sprintf("%-19s  %-11s  %5s  %4s  %4s  %4s", "Started", "Status", "Fetch", "New", "Dup", "Filt")))
+	s.WriteString(fmt.Sprintf("\n%s\n", strings.Repeat("─", 58)))
+
+	for _, r := range runs {
+		var status string
+		switch {
+		case r.FinishedAt.IsZero():
+			status = "unfinished"
+		case r.Cached():
+			status = "cached"
+		case r.APIStatus == 0:
+			status = "no response"
+		default:
+			status = fmt.Sprintf("HTTP %d", r.APIStatus)
+		}
+
+		line := fmt.Sprintf("%-19s  %-11s  %5d  %4d  %4d  %4d",
+			r.StartedAt.Local().Format("2006-01-02 15:04:05"), status, r.Fetched, r.Inserted, r.Duplicates, r.Skipped)
+		if r.Failed() {
+			s.WriteString(opponentStyle.Render(line))
+			if r.Error != "" {
+				s.WriteString("\n    " + opponentStyle.Render(r.Error))
+			}
+		} else {
+			s.WriteString(teamStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
+
+// cardName returns the card's name, marking cards played in their evolved form.
+func cardName(c types.Card) string {
+	if c.Evolved() {
+		return c.Name + " (Evo)"
+	}
+	return c.Name
+}
+
+// cardLevel formats the card's level along with its star level, if any.
+func cardLevel(c types.Card) string {
+	if c.StarLevel > 0 {
+		return fmt.Sprintf("Lvl %d ★%d", c.Level, c.StarLevel)
+	}
+	return fmt.Sprintf("Lvl %d", c.Level)
+}
+
 // UpdateStatus updates the status message
 func UpdateStatus(text string) tea.Cmd {
 	return func() tea.Msg {
@@ -719,6 +885,44 @@ func DisplayAnalytics(a analytics.Analytics) string {
 			opponentStyle.Render(note)))
 	}
 
+	// Tower troop section
+	if len(a.TowerTroops) > 0 {
+		s.WriteString("\n")
+		s.WriteString(battleHeaderStyle.Render("TOWER TROOPS"))
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("%s\n", strings.Repeat("─", 50)))
+
+		for _, troop := range a.TowerTroops {
+			s.WriteString(fmt.Sprintf("%s: %s (%.1f%% W/L: %d-%d)\n",
+				headerStyle.Render(troop.CardName),
+				makeProgressBar(troop.WinRate, 10),
+				troop.WinRate,
+				troop.Wins,
+				troop.Battles-troop.Wins))
+		}
+	}
+
+	// Evolution usage section
+	var evolved []analytics.CardImpact
+	for _, card := range a.Cards {
+		if card.Evolved.Battles > 0 {
+			evolved = append(evolved, card)
+		}
+	}
+	if len(evolved) > 0 {
+		s.WriteString("\n")
+		s.WriteString(battleHeaderStyle.Render("EVOLUTIONS"))
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("%s\n", strings.Repeat("─", 50)))
+
+		for _, card := range evolved {
+			s.WriteString(fmt.Sprintf("%s: %d battles evolved (%.1f%% win rate)\n",
+				headerStyle.Render(card.CardName),
+				card.Evolved.Battles,
+				card.Evolved.WinRate))
+		}
+	}
+
 	// Challenge proof section
 	s.WriteString("\n")
 	s.WriteString(battleHeaderStyle.Render("JOURNEY SUMMARY"))